@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/tui"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// runExplain is `budget_tui explain`: it shows how a description would be
+// categorized
+func runExplain(args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	amount := flags.Float64("amount", 0, "the transaction's amount")
+	income := flags.Bool("income", false, "explain it as income rather than an expense")
+	account := flags.String("account", "", "the account the transaction is in")
+	date := flags.String("date", time.Now().Format("2006-01-02"), "the transaction's date, YYYY-MM-DD")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: budget_tui explain [--amount N] [--income] [--account NAME] [--date YYYY-MM-DD] DESCRIPTION")
+	}
+	when, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		return fmt.Errorf("invalid --date %q: use YYYY-MM-DD", *date)
+	}
+
+	b, err := budget.LoadBudget()
+	if err != nil {
+		return err
+	}
+	c := categorizer.NewCategorizer()
+	c.Train(b.Transactions)
+
+	transType := budget.Expense
+	if *income {
+		transType = budget.Income
+	}
+	e := c.Explain(budget.Transaction{
+		Description: strings.Join(flags.Args(), " "),
+		Amount:      math.Abs(*amount),
+		Type:        transType,
+		Account:     *account,
+		Date:        when,
+	})
+	for _, line := range tui.ExplanationSummary(e) {
+		fmt.Println(line)
+	}
+	fmt.Println("\nRules tried:")
+	for _, i := range tui.ExplainOrder(e) {
+		line := tui.FormatEvaluation(e.Evaluations[i])
+		if i == e.Winner {
+			line += "  <- winner"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runRecategorize is `budget_tui recategorize`: it prints the category
+// changes the rules would make and makes them with --apply
+func runRecategorize(args []string) error {
+	flags := flag.NewFlagSet("recategorize", flag.ContinueOnError)
+	from := flags.String("from", "", "only transactions on or after this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "only transactions on or before this date (YYYY-MM-DD)")
+	source := flags.String("source", "", `only transactions imported with this format, or "manual"`)
+	category := flags.String("category", "", "only transactions currently in this category")
+	protect := flags.Bool("protect-manual", true, "leave categories set by hand alone")
+	apply := flags.Bool("apply", false, "make the changes instead of only listing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := tui.ParseRecategorizeFilter([4]string{*from, *to, *source, *category}, *protect)
+	if err != nil {
+		return err
+	}
+
+	b, err := budget.LoadBudget()
+	if err != nil {
+		return err
+	}
+	c := categorizer.NewCategorizer()
+	c.Train(b.Transactions)
+
+	changes := c.PlanRecategorize(b.Transactions, filter)
+	for _, change := range changes {
+		fmt.Printf("%s  %-30s  %s -> %s (%.0f%%)\n", change.Date.Format("2006-01-02"),
+			change.Description, change.OldCategory, change.NewCategory, change.Confidence*100)
+	}
+	if !*apply {
+		fmt.Printf("\n%d transactions would change. Run again with --apply to change them.\n", len(changes))
+		return nil
+	}
+
+	count := c.ApplyRecategorize(b, changes)
+	if count == 0 {
+		fmt.Println("\nNothing to recategorize.")
+		return nil
+	}
+	if err := b.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("\nRecategorized %d transactions.\n", count)
+	return nil
+}
+
+// runSuggestRules lists suggested rules for the budget's uncategorized
+// transactions, for `budget_tui suggest-rules`
+func runSuggestRules() error {
+	b, err := budget.LoadBudget()
+	if err != nil {
+		return err
+	}
+	c := categorizer.NewCategorizer()
+	c.Train(b.Transactions)
+
+	suggestions := c.SuggestRules(b.Transactions)
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestions: uncategorized transactions don't share a merchant.")
+		return nil
+	}
+	for _, suggestion := range suggestions {
+		category := suggestion.Rule.Category
+		if category == "" {
+			category = "?"
+		}
+		fmt.Printf("%s (%d transactions) -> %s\n", strings.Join(suggestion.Rule.Keywords, ", "), len(suggestion.Matches), category)
+		fmt.Printf("  pattern: %s\n", suggestion.Rule.Pattern)
+		for _, example := range suggestion.Examples {
+			fmt.Printf("  e.g. %s\n", example)
+		}
+	}
+	fmt.Println("\nPress S on the dashboard to accept them.")
+	return nil
+}
+
+// runLintRules is `budget_tui lint-rules`: it lists problems with the rules
+// and fails if there are any
+func runLintRules() error {
+	b, err := budget.LoadBudget()
+	if err != nil {
+		return err
+	}
+	c := categorizer.NewCategorizer()
+	findings := c.LintRules(b.Transactions)
+	if len(findings) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	rules := c.Rules()
+	for i, finding := range findings {
+		if i == 0 || finding.Rule != findings[i-1].Rule {
+			rule := rules[finding.Rule]
+			fmt.Printf("%d %s %s\n", rule.Priority, rule.Category, rule.Pattern)
+		}
+		fmt.Printf("  %s: %s\n", finding.Kind, finding.Message)
+		for _, example := range finding.Examples {
+			fmt.Printf("    e.g. %s\n", example)
+		}
+	}
+	fmt.Printf("\n%d problems found. Press R on the dashboard to fix them.\n", len(findings))
+	return fmt.Errorf("%d lint problems", len(findings))
+}
+
+// runExportRules is `budget_tui export-rules`: it writes the selected rules
+// to a rule pack file to share
+func runExportRules(args []string) error {
+	flags := flag.NewFlagSet("export-rules", flag.ContinueOnError)
+	name := flags.String("name", "", "the pack's name")
+	version := flags.String("version", "1.0.0", "the pack's version")
+	description := flags.String("description", "", "what the pack is for")
+	categories := flags.String("category", "", "only rules for these comma-separated categories")
+	pack := flags.String("pack", "", "only rules installed from this pack")
+	custom := flags.Bool("custom", true, "leave out the built-in rules")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: budget_tui export-rules --name NAME [--version V] [--category A,B] [--pack P] FILE")
+	}
+
+	var wanted []string
+	for _, category := range strings.Split(*categories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			wanted = append(wanted, strings.ToLower(category))
+		}
+	}
+	c := categorizer.NewCategorizer()
+	rulePack := c.ExportPack(*name, *version, *description, func(rule categorizer.CategorizationRule) bool {
+		switch {
+		case *custom && rule.Pack == "" && categorizer.IsDefaultRule(rule):
+			return false
+		case *pack != "" && rule.Pack != *pack:
+			return false
+		case len(wanted) > 0 && !slices.Contains(wanted, strings.ToLower(rule.Category)):
+			return false
+		}
+		return true
+	})
+	if err := rulePack.Write(flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d rules to %s as %s %s.\n", len(rulePack.Rules), flags.Arg(0), rulePack.Name, rulePack.Version)
+	return nil
+}
+
+// runImportRules is `budget_tui import-rules`: it installs a rule pack file
+func runImportRules(args []string) error {
+	flags := flag.NewFlagSet("import-rules", flag.ContinueOnError)
+	onConflict := flags.String("on-conflict", "skip", "skip, overwrite or rename when the pack or a rule is already there")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: budget_tui import-rules [--on-conflict skip|overwrite|rename] FILE")
+	}
+	policy, err := categorizer.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		return err
+	}
+	rulePack, err := categorizer.ReadRulePack(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	c := categorizer.NewCategorizer()
+	result, err := c.ImportPack(rulePack, flags.Arg(0), policy)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !result.Installed:
+		fmt.Printf("%s is already installed; nothing imported. Use --on-conflict overwrite to update it.\n", rulePack.Name)
+		return nil
+	case result.Replaced != "":
+		fmt.Printf("Updated %s from %s to %s", result.Name, result.Replaced, rulePack.Version)
+	default:
+		fmt.Printf("Installed %s %s", result.Name, rulePack.Version)
+	}
+	fmt.Printf(": %d rules added, %d overwritten, %d skipped as already there.\n", result.Added, result.Overwritten, result.Skipped)
+	return nil
+}
+
+// runRulePacks is `budget_tui rule-packs`: it lists the installed rule
+// packs, or removes one with its rules
+func runRulePacks(args []string) error {
+	c := categorizer.NewCategorizer()
+	if len(args) > 0 {
+		if args[0] != "remove" || len(args) != 2 {
+			return errors.New("usage: budget_tui rule-packs [remove NAME]")
+		}
+		removed, err := c.RemovePack(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s and its %d rules.\n", args[1], removed)
+		return nil
+	}
+
+	packs := c.Packs()
+	if len(packs) == 0 {
+		fmt.Println("No rule packs installed. Add one with import-rules.")
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, rule := range c.Rules() {
+		counts[rule.Pack]++
+	}
+	for _, info := range packs {
+		fmt.Printf("%s %s (%d rules), installed %s from %s\n", info.Name, info.Version, counts[info.Name],
+			info.InstalledAt.Format("2006-01-02"), info.Source)
+		if info.Description != "" {
+			fmt.Printf("  %s\n", info.Description)
+		}
+	}
+	return nil
+}
+
+// printRuleStats is `budget_tui rule-stats`: it reports how often each
+// rule's suggestions were kept and the confidence that has earned it
+func printRuleStats() {
+	c := categorizer.NewCategorizer()
+	fmt.Printf("%-40s %7s %9s %9s  %-12s %s\n", "RULE", "MATCHES", "OVERRIDES", "PRECISION", "LAST MATCHED", "CONFIDENCE")
+	for _, report := range c.RuleReports() {
+		rule := report.Rule
+		name := fmt.Sprintf("%d %s %s", rule.Priority, rule.Category, rule.Pattern)
+		name = tui.Truncate(name, 40, "…")
+		total := report.Stats.Total()
+		precision, last := "-", "-"
+		if total.Matches > 0 {
+			precision = fmt.Sprintf("%.0f%%", total.Precision()*100)
+		}
+		if !report.Stats.LastMatched.IsZero() {
+			last = report.Stats.LastMatched.Format("2006-01-02")
+		}
+		fmt.Printf("%-40s %7d %9d %9s  %-12s %s\n", name, total.Matches, total.Overrides, precision, last, tui.FormatCalibration(report))
+	}
+}
@@ -17,15 +17,18 @@ func GetSpendingByCategory(b *budget.Budget) []CategorySpending {
 	categoryTotals := make(map[string]CategorySpending)
 
 	for _, t := range b.Transactions {
-		if t.Type == budget.Expense {
-			if existing, ok := categoryTotals[t.Category]; ok {
-				existing.Amount += t.Amount
+		if t.Type != budget.Expense || t.IsTransfer {
+			continue
+		}
+		for _, split := range t.CategoryAmounts() {
+			if existing, ok := categoryTotals[split.Category]; ok {
+				existing.Amount += split.Amount
 				existing.Count++
-				categoryTotals[t.Category] = existing
+				categoryTotals[split.Category] = existing
 			} else {
-				categoryTotals[t.Category] = CategorySpending{
-					Category: t.Category,
-					Amount:   t.Amount,
+				categoryTotals[split.Category] = CategorySpending{
+					Category: split.Category,
+					Amount:   split.Amount,
 					Count:    1,
 				}
 			}
@@ -65,7 +68,7 @@ func GetThisMonthTotals(b *budget.Budget) (income, expenses float64) {
 	currentYear := now.Year()
 
 	for _, t := range b.Transactions {
-		if t.Date.Month() == currentMonth && t.Date.Year() == currentYear {
+		if t.Date.Month() == currentMonth && t.Date.Year() == currentYear && !t.IsTransfer {
 			if t.Type == budget.Income {
 				income += t.Amount
 			} else {
@@ -0,0 +1,34 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestGetSpendingByCategory(t *testing.T) {
+	b := &budget.Budget{Transactions: []budget.Transaction{
+		{Category: "Groceries", Amount: 100, Type: budget.Expense,
+			Splits: []budget.Split{{Category: "Household", Amount: 30}, {Category: "Groceries", Amount: 70}}},
+		{Category: "Groceries", Amount: 20, Type: budget.Expense},
+		{Category: "Transfers", Amount: 500, Type: budget.Expense, IsTransfer: true},
+		{Category: "Salary", Amount: 1000, Type: budget.Income},
+	}}
+
+	want := make(map[string]budget.CategorySpending)
+	for _, spending := range b.GetSpendingByCategory() {
+		want[spending.Category] = spending
+	}
+	got := GetSpendingByCategory(b)
+	if len(got) != len(want) {
+		t.Fatalf("got %d categories, want %d: %v", len(got), len(want), got)
+	}
+	for _, spending := range got {
+		if w := want[spending.Category]; spending.Amount != w.Amount || spending.Count != w.Count {
+			t.Errorf("%s = %.2f in %d, budget says %.2f in %d", spending.Category, spending.Amount, spending.Count, w.Amount, w.Count)
+		}
+	}
+	if got[0].Category != "Groceries" || got[0].Amount != 90 {
+		t.Errorf("top category = %s %.2f, want Groceries 90.00", got[0].Category, got[0].Amount)
+	}
+}
@@ -25,14 +25,58 @@ type Transaction struct {
 	Type        TransactionType `json:"type"`
 	Date        time.Time       `json:"date"`
 	// Import-specific fields
-	OriginalDescription string  `json:"original_description,omitempty"`
-	ImportSource        string  `json:"import_source,omitempty"`
-	Confidence          float64 `json:"confidence,omitempty"`
-	IsImported          bool    `json:"is_imported,omitempty"`
+	OriginalDescription string   `json:"original_description,omitempty"`
+	ImportSource        string   `json:"import_source,omitempty"`
+	Confidence          float64  `json:"confidence,omitempty"`
+	IsImported          bool     `json:"is_imported,omitempty"`
+	ImportSessionID     string   `json:"import_session_id,omitempty"`
+	RunningBalance      float64  `json:"running_balance,omitempty"`
+	Account             string   `json:"account,omitempty"`
+	ExternalID          string   `json:"external_id,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	// Transfers move money between our own accounts and are left out of
+	// income and expense totals
+	IsTransfer bool `json:"is_transfer,omitempty"`
+	// Status tracks reconciliation against bank statements
+	Status ClearedStatus `json:"status,omitempty"`
+	// ManualCategory is set when the user chose the category instead of
+	// taking the suggested one
+	ManualCategory bool `json:"manual_category,omitempty"`
+	// Payee is who the money went to or came from, when a rule names it
+	Payee string `json:"payee,omitempty"`
+	// Splits share the amount between categories; without them it all
+	// belongs to Category
+	Splits []Split `json:"splits,omitempty"`
+	// CategorizedBy identifies the rule that suggested the category and
+	// MatchedBy how it matched, to keep score of how often it is right
+	CategorizedBy string `json:"categorized_by,omitempty"`
+	MatchedBy     string `json:"matched_by,omitempty"`
+}
+
+// Split is the part of a transaction's amount that belongs to a category
+type Split struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+// CategoryAmounts returns how much of the transaction belongs to each
+// category
+func (t Transaction) CategoryAmounts() []Split {
+	if len(t.Splits) == 0 {
+		return []Split{{Category: t.Category, Amount: t.Amount}}
+	}
+	return t.Splits
+}
+
+// CategorySetByUser reports whether the category was chosen by the user:
+// typed in when the transaction was added, or changed from the suggestion
+func (t Transaction) CategorySetByUser() bool {
+	return t.ManualCategory || !t.IsImported
 }
 
 type Budget struct {
-	Transactions []Transaction `json:"transactions"`
+	Transactions    []Transaction    `json:"transactions"`
+	Reconciliations []Reconciliation `json:"reconciliations,omitempty"`
 }
 
 func NewBudget() *Budget {
@@ -59,10 +103,37 @@ func (b *Budget) AddTransaction(amount float64, description, category string, tT
 	b.Transactions = append(b.Transactions, transaction)
 }
 
+// RemoveImportSession deletes every transaction created by the given import
+// session and returns how many were removed. Nothing is removed if any of
+// them has been reconciled.
+func (b *Budget) RemoveImportSession(sessionID string) (int, error) {
+	if sessionID == "" {
+		return 0, nil
+	}
+
+	for _, t := range b.Transactions {
+		if t.ImportSessionID == sessionID && t.IsLocked() {
+			return 0, ErrReconciled
+		}
+	}
+
+	kept := b.Transactions[:0]
+	removed := 0
+	for _, t := range b.Transactions {
+		if t.ImportSessionID == sessionID {
+			removed++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	b.Transactions = kept
+	return removed, nil
+}
+
 func (b *Budget) GetTotalIncome() float64 {
 	total := 0.0
 	for _, t := range b.Transactions {
-		if t.Type == Income {
+		if t.Type == Income && !t.IsTransfer {
 			total += t.Amount
 		}
 	}
@@ -72,7 +143,7 @@ func (b *Budget) GetTotalIncome() float64 {
 func (b *Budget) GetTotalExpenses() float64 {
 	total := 0.0
 	for _, t := range b.Transactions {
-		if t.Type == Expense {
+		if t.Type == Expense && !t.IsTransfer {
 			total += t.Amount
 		}
 	}
@@ -86,8 +157,11 @@ func (b *Budget) GetBalance() float64 {
 func (b *Budget) GetTransactionsByCategory(category string) []Transaction {
 	var transactions []Transaction
 	for _, t := range b.Transactions {
-		if t.Category == category {
-			transactions = append(transactions, t)
+		for _, split := range t.CategoryAmounts() {
+			if split.Category == category {
+				transactions = append(transactions, t)
+				break
+			}
 		}
 	}
 	return transactions
@@ -102,12 +176,14 @@ type CategorySpending struct {
 func (b *Budget) GetSpendingByCategory() []CategorySpending {
 	categoryMap := make(map[string]*CategorySpending)
 	for _, t := range b.Transactions {
-		if t.Type == Expense {
-			if _, exists := categoryMap[t.Category]; !exists {
-				categoryMap[t.Category] = &CategorySpending{Category: t.Category, Amount: 0, Count: 0}
+		if t.Type == Expense && !t.IsTransfer {
+			for _, split := range t.CategoryAmounts() {
+				if _, exists := categoryMap[split.Category]; !exists {
+					categoryMap[split.Category] = &CategorySpending{Category: split.Category, Amount: 0, Count: 0}
+				}
+				categoryMap[split.Category].Amount += split.Amount
+				categoryMap[split.Category].Count++
 			}
-			categoryMap[t.Category].Amount += t.Amount
-			categoryMap[t.Category].Count++
 		}
 	}
 	var categories []CategorySpending
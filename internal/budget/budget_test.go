@@ -0,0 +1,53 @@
+package budget
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRemoveImportSession(t *testing.T) {
+	transactions := func(status ClearedStatus) []Transaction {
+		return []Transaction{
+			{ID: "a", ImportSessionID: "s1"},
+			{ID: "b", ImportSessionID: "s2"},
+			{ID: "c", ImportSessionID: "s1", Status: status},
+			{ID: "d"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		session     string
+		status      ClearedStatus
+		wantRemoved int
+		wantErr     error
+		wantLeft    []string
+	}{
+		{"session", "s1", Cleared, 2, nil, []string{"b", "d"}},
+		{"other session", "s2", Cleared, 1, nil, []string{"a", "c", "d"}},
+		{"unknown session", "s3", Cleared, 0, nil, []string{"a", "b", "c", "d"}},
+		{"no session", "", Cleared, 0, nil, []string{"a", "b", "c", "d"}},
+		{"reconciled", "s1", Reconciled, 0, ErrReconciled, []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Budget{Transactions: transactions(tt.status)}
+			removed, err := b.RemoveImportSession(tt.session)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RemoveImportSession() error = %v, want %v", err, tt.wantErr)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("removed %d, want %d", removed, tt.wantRemoved)
+			}
+			var left []string
+			for _, transaction := range b.Transactions {
+				left = append(left, transaction.ID)
+			}
+			if !slices.Equal(left, tt.wantLeft) {
+				t.Errorf("left %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type ClearedStatus string
+
+const (
+	Uncleared  ClearedStatus = ""
+	Cleared    ClearedStatus = "cleared"
+	Reconciled ClearedStatus = "reconciled"
+)
+
+// ErrReconciled is returned when changing a transaction that has already
+// been reconciled against a bank statement
+var ErrReconciled = errors.New("transaction is reconciled and locked")
+
+// Reconciliation is a bank statement an account is checked against. The
+// cleared transactions up to StatementDate, added to OpeningBalance, should
+// come to EndingBalance.
+type Reconciliation struct {
+	Account        string    `json:"account"`
+	StatementDate  time.Time `json:"statement_date"`
+	OpeningBalance float64   `json:"opening_balance"`
+	EndingBalance  float64   `json:"ending_balance"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+// IsLocked reports whether the transaction is reconciled and so can no
+// longer be edited
+func (t Transaction) IsLocked() bool {
+	return t.Status == Reconciled
+}
+
+// SignedAmount is the transaction's effect on its account's balance
+func (t Transaction) SignedAmount() float64 {
+	if t.Type == Expense {
+		return -t.Amount
+	}
+	return t.Amount
+}
+
+func (b *Budget) FindTransaction(id string) *Transaction {
+	for i := range b.Transactions {
+		if b.Transactions[i].ID == id {
+			return &b.Transactions[i]
+		}
+	}
+	return nil
+}
+
+// UpdateTransaction applies edit to the transaction with the given ID,
+// refusing if it is reconciled
+func (b *Budget) UpdateTransaction(id string, edit func(t *Transaction)) error {
+	t := b.FindTransaction(id)
+	if t == nil {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	if t.IsLocked() {
+		return ErrReconciled
+	}
+	edit(t)
+	return nil
+}
+
+// ToggleCleared marks an uncleared transaction as cleared or clears the mark
+func (b *Budget) ToggleCleared(id string) error {
+	return b.UpdateTransaction(id, func(t *Transaction) {
+		if t.Status == Cleared {
+			t.Status = Uncleared
+		} else {
+			t.Status = Cleared
+		}
+	})
+}
+
+// Accounts lists every account that has transactions. Transactions without
+// an account belong to the unnamed account "".
+func (b *Budget) Accounts() []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, t := range b.Transactions {
+		if !seen[t.Account] {
+			seen[t.Account] = true
+			accounts = append(accounts, t.Account)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// LastReconciliation returns the most recent completed reconciliation of
+// account, or nil if it has never been reconciled
+func (b *Budget) LastReconciliation(account string) *Reconciliation {
+	var last *Reconciliation
+	for i := range b.Reconciliations {
+		r := &b.Reconciliations[i]
+		if r.Account == account && (last == nil || r.StatementDate.After(last.StatementDate)) {
+			last = r
+		}
+	}
+	return last
+}
+
+// NewReconciliation starts a reconciliation of account that picks up where
+// the last one ended
+func (b *Budget) NewReconciliation(account string) Reconciliation {
+	r := Reconciliation{Account: account, StatementDate: time.Now()}
+	if last := b.LastReconciliation(account); last != nil {
+		r.OpeningBalance = last.EndingBalance
+		r.EndingBalance = last.EndingBalance
+	}
+	return r
+}
+
+// StatementFromImport reads a statement's opening and ending balance from
+// the running balances of the account's imported transactions that haven't
+// been reconciled yet
+func (b *Budget) StatementFromImport(account string) (Reconciliation, bool) {
+	r := b.NewReconciliation(account)
+
+	var firstDay, lastDay []*Transaction
+	for i := range b.Transactions {
+		t := &b.Transactions[i]
+		if t.Account != account || t.IsLocked() || !t.IsImported || t.RunningBalance == 0 {
+			continue
+		}
+		day := calendarDate(t.Date)
+		switch {
+		case firstDay == nil || day.Before(calendarDate(firstDay[0].Date)):
+			firstDay = []*Transaction{t}
+		case day.Equal(calendarDate(firstDay[0].Date)):
+			firstDay = append(firstDay, t)
+		}
+		switch {
+		case lastDay == nil || day.After(calendarDate(lastDay[0].Date)):
+			lastDay = []*Transaction{t}
+		case day.Equal(calendarDate(lastDay[0].Date)):
+			lastDay = append(lastDay, t)
+		}
+	}
+	if lastDay == nil {
+		return r, false
+	}
+
+	first, _ := balanceEnds(firstDay)
+	_, last := balanceEnds(lastDay)
+	if b.LastReconciliation(account) == nil {
+		r.OpeningBalance = first.RunningBalance - first.SignedAmount()
+	}
+	r.EndingBalance = last.RunningBalance
+	r.StatementDate = last.Date
+	return r, true
+}
+
+// balanceEnds finds the earliest and latest of one day's rows by following
+// their running balances, as banks list a day's rows oldest or newest
+// first. The earliest is the row no other row's balance leads into and the
+// latest the row no other row carries on from. When the balances don't
+// settle it, the rows keep their order in the file.
+func balanceEnds(day []*Transaction) (first, last *Transaction) {
+	cents := func(amount float64) int64 {
+		return int64(math.Round(amount * 100))
+	}
+	opening := make(map[int64]bool)
+	closing := make(map[int64]bool)
+	for _, t := range day {
+		opening[cents(t.RunningBalance-t.SignedAmount())] = true
+		closing[cents(t.RunningBalance)] = true
+	}
+
+	var starts, ends []*Transaction
+	for _, t := range day {
+		if !closing[cents(t.RunningBalance-t.SignedAmount())] {
+			starts = append(starts, t)
+		}
+		if !opening[cents(t.RunningBalance)] {
+			ends = append(ends, t)
+		}
+	}
+	if len(starts) == 1 && len(ends) == 1 {
+		return starts[0], ends[0]
+	}
+	return day[0], day[len(day)-1]
+}
+
+// ReconcileCandidates returns the indexes of the account's unreconciled
+// transactions dated on or before the statement date, oldest first
+func (b *Budget) ReconcileCandidates(r Reconciliation) []int {
+	through := calendarDate(r.StatementDate)
+
+	var indexes []int
+	for i, t := range b.Transactions {
+		if t.Account == r.Account && !t.IsLocked() && !calendarDate(t.Date).After(through) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return b.Transactions[indexes[i]].Date.Before(b.Transactions[indexes[j]].Date)
+	})
+	return indexes
+}
+
+// ClearedBalance is the opening balance plus every cleared transaction in
+// the statement period
+func (b *Budget) ClearedBalance(r Reconciliation) float64 {
+	balance := r.OpeningBalance
+	for _, i := range b.ReconcileCandidates(r) {
+		if b.Transactions[i].Status == Cleared {
+			balance += b.Transactions[i].SignedAmount()
+		}
+	}
+	return balance
+}
+
+// ReconcileDifference is how far the cleared balance is from the statement.
+// Zero means the account is reconciled.
+func (b *Budget) ReconcileDifference(r Reconciliation) float64 {
+	return math.Round((r.EndingBalance-b.ClearedBalance(r))*100) / 100
+}
+
+// FinishReconciliation locks the cleared transactions in the statement
+// period once they match the statement, and records the reconciliation. It
+// returns how many transactions were reconciled.
+func (b *Budget) FinishReconciliation(r Reconciliation) (int, error) {
+	if diff := b.ReconcileDifference(r); diff != 0 {
+		return 0, fmt.Errorf("out of balance by %.2f", diff)
+	}
+
+	count := 0
+	for _, i := range b.ReconcileCandidates(r) {
+		if b.Transactions[i].Status == Cleared {
+			b.Transactions[i].Status = Reconciled
+			count++
+		}
+	}
+
+	r.CompletedAt = time.Now()
+	b.Reconciliations = append(b.Reconciliations, r)
+	return count, nil
+}
+
+// calendarDate is the day t falls on where it was recorded. Imported
+// transactions are dated at midnight UTC and ones added by hand in local
+// time, so comparing instants would shift days west or east of UTC.
+func calendarDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,108 @@
+package budget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcileCandidates(t *testing.T) {
+	// West of UTC, midnight UTC on the 2nd is still the 1st locally
+	west := time.FixedZone("UTC-5", -5*60*60)
+	east := time.FixedZone("UTC+9", 9*60*60)
+
+	tests := []struct {
+		name      string
+		statement time.Time
+		date      time.Time
+		want      bool
+	}{
+		{"imported on the statement date", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), true},
+		{"imported the day after", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"statement entered west of utc", time.Date(2024, 1, 31, 0, 0, 0, 0, west), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"added by hand late west of utc", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 23, 0, 0, 0, west), true},
+		{"added by hand early east of utc", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 1, 0, 0, 0, east), false},
+		{"earlier", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Budget{Transactions: []Transaction{{ID: "a", Account: "Checking", Amount: 10, Type: Expense, Date: tt.date}}}
+			got := len(b.ReconcileCandidates(Reconciliation{Account: "Checking", StatementDate: tt.statement})) == 1
+			if got != tt.want {
+				t.Errorf("in statement period = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinishReconciliation(t *testing.T) {
+	b := &Budget{Transactions: []Transaction{
+		{ID: "pay", Account: "Checking", Amount: 100, Type: Income, Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Status: Cleared},
+		{ID: "rent", Account: "Checking", Amount: 40, Type: Expense, Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Status: Cleared},
+		{ID: "later", Account: "Checking", Amount: 5, Type: Expense, Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Status: Cleared},
+		{ID: "other", Account: "Savings", Amount: 7, Type: Expense, Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Status: Cleared},
+	}}
+	r := Reconciliation{Account: "Checking", StatementDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), OpeningBalance: 20, EndingBalance: 80}
+
+	if _, err := b.FinishReconciliation(Reconciliation{Account: r.Account, StatementDate: r.StatementDate, EndingBalance: 1}); err == nil {
+		t.Fatal("out of balance reconciliation finished")
+	}
+	count, err := b.FinishReconciliation(r)
+	if err != nil {
+		t.Fatalf("FinishReconciliation() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("reconciled %d transactions, want 2", count)
+	}
+	for _, tt := range []struct {
+		id     string
+		locked bool
+	}{{"pay", true}, {"rent", true}, {"later", false}, {"other", false}} {
+		if got := b.FindTransaction(tt.id).IsLocked(); got != tt.locked {
+			t.Errorf("%s locked = %v, want %v", tt.id, got, tt.locked)
+		}
+	}
+}
+
+func TestStatementFromImport(t *testing.T) {
+	jan := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	// Opening balance 100: +50 and -20 on the 2nd, then -30 and -10 on the 9th
+	oldestFirst := []Transaction{
+		{ID: "pay", Amount: 50, Type: Income, Date: jan(2), RunningBalance: 150},
+		{ID: "gas", Amount: 20, Type: Expense, Date: jan(2), RunningBalance: 130},
+		{ID: "food", Amount: 30, Type: Expense, Date: jan(9), RunningBalance: 100},
+		{ID: "coffee", Amount: 10, Type: Expense, Date: jan(9), RunningBalance: 90},
+	}
+	newestFirst := make([]Transaction, len(oldestFirst))
+	for i, t := range oldestFirst {
+		newestFirst[len(oldestFirst)-1-i] = t
+	}
+
+	tests := []struct {
+		name         string
+		transactions []Transaction
+	}{
+		{"oldest first", oldestFirst},
+		{"newest first", newestFirst},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Budget{}
+			for _, txn := range tt.transactions {
+				txn.Account = "Checking"
+				txn.IsImported = true
+				b.Transactions = append(b.Transactions, txn)
+			}
+			r, ok := b.StatementFromImport("Checking")
+			if !ok {
+				t.Fatal("StatementFromImport() found no running balances")
+			}
+			if r.OpeningBalance != 100 || r.EndingBalance != 90 {
+				t.Errorf("balances = %.2f to %.2f, want 100.00 to 90.00", r.OpeningBalance, r.EndingBalance)
+			}
+			if !r.StatementDate.Equal(jan(9)) {
+				t.Errorf("statement date = %s, want 2024-01-09", r.StatementDate.Format("2006-01-02"))
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds user settings that aren't part of the budget itself
+type Config struct {
+	// DownloadsDir is where the import file browser starts
+	DownloadsDir string `json:"downloads_dir,omitempty"`
+
+	// WatchDir is checked for new statements every WatchIntervalSeconds
+	// (30 when unset). New files are parsed and queued for review.
+	WatchDir             string `json:"watch_dir,omitempty"`
+	WatchIntervalSeconds int    `json:"watch_interval_seconds,omitempty"`
+	// AutoImport skips review for watched files whose rows are all
+	// categorized with at least AutoImportConfidence (0.9 when unset)
+	AutoImport           bool    `json:"auto_import,omitempty"`
+	AutoImportConfidence float64 `json:"auto_import_confidence,omitempty"`
+	// Locale names the number and date conventions imports use instead of
+	// each format's own, like "en-GB"; empty keeps the format's
+	Locale string `json:"locale,omitempty"`
+}
+
+func getConfigPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".budget_tui_config.json")
+}
+
+func LoadConfig() (*Config, error) {
+	filePath := getConfigPath()
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return &Config{}, err
+	}
+
+	return &c, nil
+}
+
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getConfigPath(), data, 0644)
+}
+
+// GetDownloadsDir returns the configured downloads directory, falling back
+// to ~/Downloads and then the home directory
+func (c *Config) GetDownloadsDir() string {
+	if c.DownloadsDir != "" {
+		return ExpandHome(c.DownloadsDir)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	downloads := filepath.Join(homeDir, "Downloads")
+	if info, err := os.Stat(downloads); err == nil && info.IsDir() {
+		return downloads
+	}
+	return homeDir
+}
+
+func (c *Config) GetWatchInterval() time.Duration {
+	if c.WatchIntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.WatchIntervalSeconds) * time.Second
+}
+
+func (c *Config) GetAutoImportConfidence() float64 {
+	if c.AutoImportConfidence <= 0 {
+		return 0.9
+	}
+	return c.AutoImportConfidence
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path == "~" || len(path) > 1 && path[0] == '~' && os.IsPathSeparator(path[1]) {
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, path[1:])
+	}
+	return path
+}
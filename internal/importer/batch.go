@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// FileSummary is one file's part of a batch import
+type FileSummary struct {
+	Path         string   `json:"path"`
+	Hash         string   `json:"hash,omitempty"`
+	Format       string   `json:"format,omitempty"`
+	Encoding     Encoding `json:"encoding,omitempty"`
+	TotalRows    int      `json:"total_rows"`
+	SuccessCount int      `json:"success_count"`
+	Duplicates   int      `json:"duplicates"`
+	Errors       []string `json:"errors,omitempty"`
+	FirstRow     int      `json:"first_row"` // index of the file's first row in the merged result
+	// Fixed holds the rows fixed during review, which come after every
+	// file's parsed rows
+	Fixed []int `json:"fixed,omitempty"`
+}
+
+// Failed reports whether the file couldn't be read or parsed at all
+func (f FileSummary) Failed() bool {
+	return f.SuccessCount == 0 && len(f.Errors) > 0
+}
+
+// ExpandPaths resolves glob patterns such as "statements/*.csv" into a
+// sorted list of files. Patterns without wildcards are kept as they are, so
+// a missing file is reported when it is parsed.
+func ExpandPaths(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %v", pattern, err)
+		}
+		if matches == nil {
+			matches = []string{pattern}
+		}
+		sort.Strings(matches)
+
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+type parsedFile struct {
+	result *ImportResult
+	err    error
+}
+
+// parseFiles detects and parses every file concurrently. Results are in the
+// same order as paths. onProgress, when not nil, is called as each file
+// finishes.
+func parseFiles(ctx context.Context, paths []string, format *CSVFormat, enc Encoding, locale *Locale, onProgress func(ImportProgress)) []parsedFile {
+	parsed := make([]parsedFile, len(paths))
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, err := ParseFile(ctx, path, format, enc, locale, nil)
+			parsed[i] = parsedFile{result: result, err: err}
+
+			if onProgress != nil {
+				mu.Lock()
+				done++
+				onProgress(ImportProgress{Stage: StageParse, File: path, Done: done, Total: len(paths)})
+				mu.Unlock()
+			}
+		}(i, path)
+	}
+	wg.Wait()
+	return parsed
+}
+
+// PrepareBatchImport parses several files at once and merges them into one
+// session for review. Each file's rows are checked for duplicates against
+// existing and against the files before it, so overlapping statements
+// don't import the same transaction twice. When format is nil each file's
+// format is detected separately, and a locale, when not nil, replaces
+// their own. Rows no file names an account for get account.
+func PrepareBatchImport(ctx context.Context, paths []string, format *CSVFormat, enc Encoding, locale *Locale, account string, existing []budget.Transaction, c *categorizer.Categorizer, onProgress func(ImportProgress)) (*ImportSession, *ImportResult, error) {
+	session := &ImportSession{
+		ID:        budget.GenerateID(),
+		FileName:  fmt.Sprintf("%d files", len(paths)),
+		Source:    "Batch",
+		Account:   account,
+		Status:    "reviewing",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if len(paths) == 0 {
+		return session, nil, &ImportError{Stage: StageOpen, File: session.FileName, Err: fmt.Errorf("no files to import")}
+	}
+
+	merged := &ImportResult{Transactions: []budget.Transaction{}, Encoding: enc, Account: account, FileFormats: make(map[string]CSVFormat)}
+	index := NewDuplicateIndex(existing)
+	formats := make(map[string]bool)
+	encodings := make(map[Encoding]bool)
+
+	parsed := parseFiles(ctx, paths, format, enc, locale, onProgress)
+	if ctx.Err() != nil {
+		return session, nil, &ImportError{Stage: StageParse, File: session.FileName, Err: ctx.Err()}
+	}
+
+	for i, file := range parsed {
+		summary := FileSummary{Path: paths[i], FirstRow: len(merged.Transactions)}
+
+		if file.err != nil {
+			var importErr *ImportError
+			if errors.As(file.err, &importErr) {
+				summary.Errors = []string{importErr.Err.Error()}
+			} else {
+				summary.Errors = []string{file.err.Error()}
+			}
+			merged.Errors = append(merged.Errors, RowError{
+				File:    paths[i],
+				Column:  NoColumn,
+				Kind:    ErrUnreadableFile,
+				Message: summary.Errors[0],
+			})
+			session.Files = append(session.Files, summary)
+			continue
+		}
+
+		result := file.result
+		result.SetAccount(account)
+		result.markDuplicates(index)
+		for _, t := range result.Transactions {
+			index.Add(t)
+		}
+
+		summary.Hash = result.FileHash
+		summary.Format = result.Format.Name
+		summary.Encoding = result.Encoding
+		summary.TotalRows = result.TotalRows
+		summary.SuccessCount = result.SuccessCount
+		summary.Duplicates = len(result.Duplicates)
+		summary.Errors = result.ErrorMessages()
+		session.Files = append(session.Files, summary)
+
+		for _, match := range result.Duplicates {
+			match.Index += summary.FirstRow
+			merged.Duplicates = append(merged.Duplicates, match)
+		}
+		for _, e := range result.Errors {
+			e.File = paths[i]
+			merged.Errors = append(merged.Errors, e)
+		}
+		merged.FileFormats[paths[i]] = result.Format
+		if merged.Header == nil {
+			merged.Header = result.Header
+		}
+		merged.Transactions = append(merged.Transactions, result.Transactions...)
+		merged.TotalRows += result.TotalRows
+		merged.SuccessCount += result.SuccessCount
+		if len(result.Transactions) > 0 {
+			merged.Format = result.Format
+			merged.Encoding = result.Encoding
+			formats[result.Format.Name] = true
+			encodings[result.Encoding] = true
+		}
+	}
+
+	if len(formats) > 1 {
+		merged.Format.Name = "Mixed"
+	}
+	if len(encodings) > 1 {
+		merged.Encoding = enc
+	}
+	if len(merged.Transactions) == 0 {
+		session.Errors = merged.ErrorMessages()
+		return session, nil, &ImportError{Stage: StageParse, File: session.FileName, Err: fmt.Errorf("none of the %d files could be imported", len(paths))}
+	}
+
+	session.Source = merged.Format.Name
+	session.Encoding = merged.Encoding
+	session.TotalCount = len(merged.Transactions)
+	session.Errors = merged.ErrorMessages()
+	session.SetDateRange(merged.Transactions)
+	decisions, err := categorize(ctx, merged, c, session.FileName, onProgress)
+	if err != nil {
+		return session, nil, err
+	}
+	session.Decisions = decisions
+	session.Preview = GetImportPreview(merged, session.Decisions, 10)
+
+	return session, merged, nil
+}
+
+// FileOf returns the file in a batch session that the merged row came from
+func (s *ImportSession) FileOf(row int) *FileSummary {
+	for i := range s.Files {
+		if slices.Contains(s.Files[i].Fixed, row) {
+			return &s.Files[i]
+		}
+	}
+	for i := len(s.Files) - 1; i >= 0; i-- {
+		if s.Files[i].SuccessCount > 0 && row >= s.Files[i].FirstRow {
+			return &s.Files[i]
+		}
+	}
+	return nil
+}
+
+// Paths returns the files a session was imported from
+func (s *ImportSession) Paths() []string {
+	if len(s.Files) == 0 {
+		return []string{s.FileName}
+	}
+	paths := make([]string, len(s.Files))
+	for i, file := range s.Files {
+		paths[i] = file.Path
+	}
+	return paths
+}
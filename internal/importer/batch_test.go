@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+func TestPrepareBatchImport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := categorizer.NewCategorizer()
+	format := CommonFormats[len(CommonFormats)-1]
+	dir := t.TempDir()
+	files := []struct{ name, content string }{
+		{"a.csv", "2024-01-05,Coffee,-4.50\n2024-01-06,Lunch,-12.00\n"},
+		// Overlaps the first statement by one row
+		{"b.csv", "2024-01-06,Lunch,-12.00\n2024-01-07,Rent,-900.00\n2024-01-08,Books,-20.00\n"},
+		{"missing.csv", ""},
+		{"c.csv", "2024-01-09,Paycheck,1500.00\n"},
+	}
+	var paths []string
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if file.content != "" {
+			if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		paths = append(paths, path)
+	}
+	existing := []budget.Transaction{{ID: "old", Amount: 20, Description: "Books", Type: budget.Expense, Date: day(8), Account: "Checking"}}
+
+	session, result, err := PrepareBatchImport(context.Background(), paths, &format, "", nil, "Checking", existing, c, nil)
+	if err != nil {
+		t.Fatalf("PrepareBatchImport() error = %v", err)
+	}
+	if len(result.Transactions) != 6 {
+		t.Fatalf("merged %d rows, want 6", len(result.Transactions))
+	}
+
+	tests := []struct {
+		file           string
+		wantFirst      int
+		wantSuccess    int
+		wantDuplicates int
+		wantFailed     bool
+	}{
+		{"a.csv", 0, 2, 0, false},
+		{"b.csv", 2, 3, 2, false},
+		{"missing.csv", 5, 0, 0, true},
+		{"c.csv", 5, 1, 0, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			summary := session.Files[i]
+			if filepath.Base(summary.Path) != tt.file {
+				t.Fatalf("file %d is %s, want %s", i, summary.Path, tt.file)
+			}
+			if summary.FirstRow != tt.wantFirst || summary.SuccessCount != tt.wantSuccess || summary.Duplicates != tt.wantDuplicates {
+				t.Errorf("first row %d, %d parsed, %d duplicates, want %d, %d, %d",
+					summary.FirstRow, summary.SuccessCount, summary.Duplicates, tt.wantFirst, tt.wantSuccess, tt.wantDuplicates)
+			}
+			if summary.Failed() != tt.wantFailed {
+				t.Errorf("Failed() = %v, want %v", summary.Failed(), tt.wantFailed)
+			}
+		})
+	}
+
+	var duplicates []int
+	for _, match := range result.Duplicates {
+		duplicates = append(duplicates, match.Index)
+	}
+	slices.Sort(duplicates)
+	if !slices.Equal(duplicates, []int{2, 4}) {
+		t.Errorf("duplicate rows = %v, want [2 4]", duplicates)
+	}
+	for row, want := range []string{"a.csv", "a.csv", "b.csv", "b.csv", "b.csv", "c.csv"} {
+		if got := session.FileOf(row); got == nil || filepath.Base(got.Path) != want {
+			t.Errorf("FileOf(%d) = %v, want %s", row, got, want)
+		}
+		if got := result.Transactions[row].Account; got != "Checking" {
+			t.Errorf("row %d account = %q, want Checking", row, got)
+		}
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.csv", "a.csv", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"glob sorted", join("*.csv"), join("a.csv", "b.csv")},
+		{"repeated file once", join("a.csv", "*.csv"), join("a.csv", "b.csv")},
+		{"missing file kept", join("gone.csv"), join("gone.csv")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPaths(tt.patterns)
+			if err != nil {
+				t.Fatalf("ExpandPaths() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ExpandPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,29 +1,73 @@
 package importer
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"math"
 	"os"
-	"regexp"
-	"strconv"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/Elwdipath/budget_tui/internal/budget"
-	"github.com/Elwdipath/budget_tui/pkg/categorizer"
 )
 
+// NoColumn marks an optional CSVFormat column as not present in the file
+const NoColumn = -1
+
 type CSVFormat struct {
 	Name              string
 	DateColumn        int
 	DescriptionColumn int
 	AmountColumn      int
-	DateFormat        string
-	AmountIsNegative  bool
-	HasHeader         bool
-	Delimiter         rune
+	// Optional columns, set to NoColumn when the bank doesn't provide them.
+	// DebitColumn and CreditColumn replace AmountColumn for banks that split
+	// outflows and inflows, TypeColumn holds an explicit debit/credit marker.
+	DebitColumn   int
+	CreditColumn  int
+	TypeColumn    int
+	BalanceColumn int
+	// IDColumn holds the bank's own transaction ID (e.g. FITID), used to
+	// recognize rows that were already imported
+	IDColumn int
+	// Values of TypeColumn that mark debits and credits (case-insensitive).
+	// When empty, DefaultDebitValues and DefaultCreditValues are used.
+	DebitValues      []string
+	CreditValues     []string
+	DateFormat       string
+	AmountIsNegative bool
+	HasHeader        bool
+	Delimiter        rune
+	// Locale sets the number and date conventions, LocaleUS when nil
+	Locale *Locale
+	// Encoding overrides character set detection, sniffed when empty or auto
+	Encoding Encoding
+	// JSON reads the file as JSON or NDJSON transactions (see JSONFormat)
+	// instead of delimited text
+	JSON bool
 }
 
+var (
+	DefaultDebitValues  = []string{"debit", "dr", "withdrawal", "payment", "sale"}
+	DefaultCreditValues = []string{"credit", "cr", "deposit", "refund", "return"}
+)
+
+// typeColumnHeaders are words that name a debit/credit marker column. A
+// format with a TypeColumn is only detected when the header names it with
+// one of them, so a running balance in the same place, as in Bank of
+// America's exports, isn't taken for one.
+var typeColumnHeaders = []string{"type", "dr/cr", "cr/dr", "debit/credit", "credit/debit"}
+
+// SupportedExtensions lists the file types the importer can read
+var SupportedExtensions = []string{".csv", ".txt", ".json", ".ndjson", ".jsonl"}
+
 // Common CSV formats for different banks
 var CommonFormats = []CSVFormat{
 	{
@@ -31,16 +75,55 @@ var CommonFormats = []CSVFormat{
 		DateColumn:        0,
 		DescriptionColumn: 2,
 		AmountColumn:      3,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
+		DateFormat:        "01/02/2006",
+		AmountIsNegative:  true,
+		HasHeader:         true,
+		Delimiter:         ',',
+	},
+	{
+		Name:              "Generic (Type Column)",
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        3,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
 		DateFormat:        "01/02/2006",
 		AmountIsNegative:  true,
 		HasHeader:         true,
 		Delimiter:         ',',
 	},
+	{
+		Name:              "Generic (Debit/Credit)",
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		AmountColumn:      NoColumn,
+		DebitColumn:       2,
+		CreditColumn:      3,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     4,
+		IDColumn:          NoColumn,
+		DateFormat:        "01/02/2006",
+		HasHeader:         true,
+		Delimiter:         ',',
+	},
 	{
 		Name:              "Bank of America",
 		DateColumn:        0,
 		DescriptionColumn: 1,
 		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
 		DateFormat:        "01/02/2006",
 		AmountIsNegative:  false,
 		HasHeader:         true,
@@ -51,16 +134,42 @@ var CommonFormats = []CSVFormat{
 		DateColumn:        1,
 		DescriptionColumn: 4,
 		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
 		DateFormat:        "01/02/06",
 		AmountIsNegative:  true,
 		HasHeader:         true,
 		Delimiter:         ',',
 	},
+	{
+		Name:              "Generic (Semicolon)",
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
+		DateFormat:        "02.01.2006",
+		AmountIsNegative:  true,
+		HasHeader:         true,
+		Delimiter:         ';',
+		Locale:            &LocaleDE,
+	},
 	{
 		Name:              "Generic",
 		DateColumn:        0,
 		DescriptionColumn: 1,
 		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
 		DateFormat:        "2006-01-02",
 		AmountIsNegative:  true,
 		HasHeader:         false,
@@ -71,144 +180,327 @@ var CommonFormats = []CSVFormat{
 type ImportResult struct {
 	Transactions []budget.Transaction `json:"transactions"`
 	Format       CSVFormat            `json:"format"`
-	Errors       []string             `json:"errors"`
+	Encoding     Encoding             `json:"encoding"`
+	Errors       []RowError           `json:"errors"`
+	Header       []string             `json:"header,omitempty"`
+	// FileFormats holds each file's format in a batch import, by path
+	FileFormats  map[string]CSVFormat `json:"file_formats,omitempty"`
 	TotalRows    int                  `json:"total_rows"`
 	SuccessCount int                  `json:"success_count"`
+	Duplicates   []DuplicateMatch     `json:"duplicates,omitempty"`
+	FileHash     string               `json:"file_hash,omitempty"`
+	// Account is the account chosen for the import, given to rows the file
+	// doesn't name one for
+	Account string `json:"account,omitempty"`
+
+	// duplicateRows finds a row's entry in Duplicates
+	duplicateRows map[int]int
+}
+
+// SetAccount gives the rows without an account the chosen one
+func (r *ImportResult) SetAccount(account string) {
+	r.Account = account
+	for i := range r.Transactions {
+		if r.Transactions[i].Account == "" {
+			r.Transactions[i].Account = account
+		}
+	}
+}
+
+// sampleSize is how much of a file format detection looks at
+const sampleSize = 64 * 1024
+
+// countingReader counts and hashes the bytes read from the underlying file,
+// so the file's hash comes out of the same pass as the parse
+type countingReader struct {
+	r    io.Reader
+	n    int64
+	hash hash.Hash
 }
 
-func DetectCSVFormat(filePath string) (*CSVFormat, error) {
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	c.hash.Write(p[:n])
+	return n, err
+}
+
+// DetectCSVFormat works out a file's format from its first few rows
+func DetectCSVFormat(filePath string, enc Encoding) (*CSVFormat, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %v", err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	decoded, _, err := NewDecodingReader(file, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := make([]byte, sampleSize)
+	n, err := io.ReadFull(decoded, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	return detectFormat(sample[:n], n == sampleSize, enc, nil), nil
+}
+
+// detectFormat tries each of the common formats on the first rows of sample.
+// When truncated is set the sample ends mid-file, so its last, possibly
+// partial, line is ignored. A locale, when not nil, replaces each format's
+// own.
+func detectFormat(sample []byte, truncated bool, enc Encoding, locale *Locale) *CSVFormat {
+	if truncated {
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
 
 	// Try each format
-	for _, format := range CommonFormats {
-		file.Seek(0, 0)
+	for _, common := range CommonFormats {
+		format := *common.withLocale(locale)
+		reader := csv.NewReader(bytes.NewReader(sample))
 		reader.Comma = format.Delimiter
-
-		records, err := reader.ReadAll()
-		if err != nil {
-			continue
+		reader.FieldsPerRecord = -1
+
+		// Read the header and five rows, skipping a malformed line or two
+		var records [][]string
+		for attempts := 0; attempts < 10 && len(records) < 6; attempts++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				continue
+			}
+			records = append(records, record)
 		}
 
 		if len(records) < 2 {
 			continue
 		}
+		if format.HasHeader {
+			// A first row that parses is data, not a header
+			if _, err := parseRow(&format, records[0]); err == nil || !format.headerMatches(records[0]) {
+				continue
+			}
+		}
 
 		// Test parsing a few rows
 		successCount := 0
 		testRows := records
-		if format.HasHeader && len(records) > 1 {
+		if format.HasHeader {
 			testRows = records[1:]
 		}
 
 		for i := 0; i < len(testRows) && i < 5; i++ {
-			row := testRows[i]
-			if len(row) <= max(format.DateColumn, max(format.DescriptionColumn, format.AmountColumn)) {
-				continue
-			}
-
-			// Test date parsing
-			_, err := time.Parse(format.DateFormat, strings.TrimSpace(row[format.DateColumn]))
-			if err != nil {
+			if _, err := parseRow(&format, testRows[i]); err != nil {
 				continue
 			}
-
-			// Test amount parsing
-			amountStr := strings.TrimSpace(row[format.AmountColumn])
-			_, err = strconv.ParseFloat(amountStr, 64)
-			if err != nil {
-				// Try removing common formatting
-				re := regexp.MustCompile(`[$,]`)
-				amountStr = re.ReplaceAllString(amountStr, "")
-				_, err = strconv.ParseFloat(amountStr, 64)
-				if err != nil {
-					continue
-				}
-			}
-
 			successCount++
 		}
 
 		if successCount >= 3 {
-			return &format, nil
+			format.Encoding = enc
+			return &format
 		}
 	}
 
 	// Return generic format as fallback
-	return &CommonFormats[len(CommonFormats)-1], nil
+	fallback := *CommonFormats[len(CommonFormats)-1].withLocale(locale)
+	fallback.Encoding = enc
+	return &fallback
 }
 
 func ParseCSV(filePath string, format *CSVFormat) (*ImportResult, error) {
+	return ParseFile(context.Background(), filePath, format, format.Encoding, nil, nil)
+}
+
+// ParseFile reads a statement in a single pass. When format is nil it is
+// detected from the start of the file before the rows are parsed. Rows are
+// read one at a time, so large files never have to fit in memory as raw
+// CSV, and onProgress, when not nil, is called every progressInterval rows.
+// A locale, when not nil, replaces the format's own. Errors are
+// *ImportError values naming the stage that failed.
+func ParseFile(ctx context.Context, filePath string, format *CSVFormat, enc Encoding, locale *Locale, onProgress func(ImportProgress)) (*ImportResult, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %v", err)
+		return nil, &ImportError{Stage: StageOpen, File: filePath, Err: err}
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = format.Delimiter
+	progress := ImportProgress{Stage: StageParse, File: filePath}
+	if info, err := file.Stat(); err == nil {
+		progress.TotalBytes = info.Size()
+	}
 
-	records, err := reader.ReadAll()
+	counter := &countingReader{r: file, hash: sha256.New()}
+	decoded, encoding, err := NewDecodingReader(counter, enc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %v", err)
+		return nil, &ImportError{Stage: StageDetect, File: filePath, Err: err}
 	}
+	buffered := bufio.NewReaderSize(decoded, sampleSize)
+
+	if format == nil {
+		sample, err := buffered.Peek(sampleSize)
+		if err != nil && err != io.EOF {
+			return nil, &ImportError{Stage: StageDetect, File: filePath, Err: err}
+		}
+		if looksLikeJSON(sample) {
+			format = &JSONFormat
+		} else {
+			format = detectFormat(sample, err == nil, enc, locale)
+		}
+	} else {
+		format = format.withLocale(locale)
+	}
+	progress.Format = format.Name
+
+	if format.JSON {
+		return parseJSONFile(ctx, filePath, buffered, counter, encoding, progress, onProgress)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = format.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
 
 	result := &ImportResult{
 		Transactions: []budget.Transaction{},
 		Format:       *format,
-		Errors:       []string{},
-		TotalRows:    len(records),
+		Encoding:     encoding,
+		Errors:       []RowError{},
 	}
 
-	startRow := 0
-	if format.HasHeader && len(records) > 0 {
-		startRow = 1
-	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		result.TotalRows++
+		row := result.TotalRows
 
-	for i := startRow; i < len(records); i++ {
-		row := records[i]
+		if row%100 == 0 && ctx.Err() != nil {
+			return nil, &ImportError{Stage: StageParse, File: filePath, Err: ctx.Err()}
+		}
 
-		if len(row) <= max(format.DateColumn, max(format.DescriptionColumn, format.AmountColumn)) {
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d: insufficient columns", i+1))
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, &ImportError{Stage: StageParse, File: filePath, Err: err}
+			}
+			result.Errors = append(result.Errors, RowError{
+				Row:     row,
+				Column:  NoColumn,
+				Kind:    ErrMalformedLine,
+				Message: parseErr.Err.Error(),
+				Fix:     "Quote fields that contain the delimiter, and double any quotes inside them.",
+				Record:  append([]string(nil), record...),
+			})
+			continue
+		}
+		if row == 1 && format.HasHeader {
+			result.Header = append([]string(nil), record...)
 			continue
 		}
 
-		// Parse date
-		dateStr := strings.TrimSpace(row[format.DateColumn])
-		date, err := time.Parse(format.DateFormat, dateStr)
+		transaction, err := parseRow(format, record)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d: invalid date '%s'", i+1, dateStr))
-			continue
+			rowErr := asRowError(err)
+			rowErr.Row = row
+			rowErr.Record = append([]string(nil), record...)
+			result.Errors = append(result.Errors, *rowErr)
+		} else {
+			result.Transactions = append(result.Transactions, transaction)
+			result.SuccessCount++
+		}
+
+		if onProgress != nil && row%progressInterval == 0 {
+			progress.BytesRead = counter.n
+			progress.Rows = row
+			onProgress(progress)
 		}
+	}
+
+	if onProgress != nil {
+		progress.BytesRead = counter.n
+		progress.Rows = result.TotalRows
+		onProgress(progress)
+	}
+
+	// Drain anything the CSV reader didn't need so the hash covers the file
+	io.Copy(io.Discard, counter)
+	result.FileHash = hex.EncodeToString(counter.hash.Sum(nil))
+
+	return result, nil
+}
 
-		// Parse amount
-		amountStr := strings.TrimSpace(row[format.AmountColumn])
-		re := regexp.MustCompile(`[$,() ]`)
-		amountStr = re.ReplaceAllString(amountStr, "")
+// parseRow converts a single CSV record into a transaction using the columns
+// described by format
+func parseRow(format *CSVFormat, row []string) (budget.Transaction, error) {
+	if len(row) < format.columnCount() {
+		return budget.Transaction{}, newRowError(ErrMissingColumns, NoColumn, "",
+			fmt.Sprintf("insufficient columns (%d of %d)", len(row), format.columnCount()),
+			fmt.Sprintf("Each row needs %d fields separated by '%c'. Check for a missing field or the wrong delimiter.", format.columnCount(), format.Delimiter))
+	}
 
-		amount, err := strconv.ParseFloat(amountStr, 64)
+	// Parse date
+	locale := format.locale()
+	date, err := locale.ParseDate(row[format.DateColumn], format.DateFormat)
+	if err != nil {
+		return budget.Transaction{}, newRowError(ErrInvalidDate, format.DateColumn, row[format.DateColumn],
+			err.Error(), fmt.Sprintf("Write the date like %s.", format.dateExample()))
+	}
+
+	description := strings.TrimSpace(row[format.DescriptionColumn])
+
+	// Parse amount and determine transaction type
+	var amount float64
+	var transType budget.TransactionType
+
+	if format.DebitColumn != NoColumn && format.CreditColumn != NoColumn {
+		debit, err := parseOptionalAmount(locale, row[format.DebitColumn])
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Row %d: invalid amount '%s'", i+1, row[format.AmountColumn]))
-			continue
+			return budget.Transaction{}, format.amountError(format.DebitColumn, row[format.DebitColumn])
+		}
+		credit, err := parseOptionalAmount(locale, row[format.CreditColumn])
+		if err != nil {
+			return budget.Transaction{}, format.amountError(format.CreditColumn, row[format.CreditColumn])
 		}
 
-		// Determine transaction type
-		var transType budget.TransactionType
-		description := strings.TrimSpace(row[format.DescriptionColumn])
+		switch {
+		case debit != 0:
+			amount = math.Abs(debit)
+			transType = budget.Expense
+		case credit != 0:
+			amount = math.Abs(credit)
+			transType = budget.Income
+		default:
+			return budget.Transaction{}, newRowError(ErrMissingAmount, format.DebitColumn, "",
+				"missing debit and credit amount", "Fill in the debit or the credit column.")
+		}
+	} else {
+		amount, err = locale.ParseAmount(row[format.AmountColumn])
+		if err != nil {
+			return budget.Transaction{}, format.amountError(format.AmountColumn, row[format.AmountColumn])
+		}
 
-		if format.AmountIsNegative {
+		if format.TypeColumn != NoColumn {
+			transType = format.typeFromColumn(row[format.TypeColumn])
+		}
+
+		switch {
+		case transType != "":
+			amount = math.Abs(amount)
+		case format.AmountIsNegative || format.TypeColumn != NoColumn:
 			if amount < 0 {
 				transType = budget.Expense
 				amount = -amount
 			} else {
 				transType = budget.Income
 			}
-		} else {
+		default:
 			// For formats where expenses are positive but we need to determine type from description
 			if isIncomeDescription(description) {
 				transType = budget.Income
@@ -216,24 +508,134 @@ func ParseCSV(filePath string, format *CSVFormat) (*ImportResult, error) {
 				transType = budget.Expense
 			}
 		}
+	}
+
+	transaction := budget.Transaction{
+		ID:                  budget.GenerateID(),
+		Amount:              amount,
+		Description:         description,
+		OriginalDescription: description,
+		Category:            "Uncategorized",
+		Type:                transType,
+		Date:                date,
+		ImportSource:        format.Name,
+		IsImported:          true,
+	}
 
-		transaction := budget.Transaction{
-			ID:                  budget.GenerateID(),
-			Amount:              amount,
-			Description:         description,
-			OriginalDescription: description,
-			Category:            "Uncategorized",
-			Type:                transType,
-			Date:                date,
-			ImportSource:        format.Name,
-			IsImported:          true,
+	if format.BalanceColumn != NoColumn {
+		balance, err := parseOptionalAmount(locale, row[format.BalanceColumn])
+		if err != nil {
+			rowErr := format.amountError(format.BalanceColumn, row[format.BalanceColumn])
+			rowErr.Kind = ErrInvalidBalance
+			return budget.Transaction{}, rowErr
 		}
+		transaction.RunningBalance = balance
+	}
 
-		result.Transactions = append(result.Transactions, transaction)
-		result.SuccessCount++
+	if format.IDColumn != NoColumn {
+		transaction.ExternalID = strings.TrimSpace(row[format.IDColumn])
 	}
 
-	return result, nil
+	return transaction, nil
+}
+
+// amountError reports an amount the format's locale can't read
+func (f *CSVFormat) amountError(column int, value string) *RowError {
+	message := fmt.Sprintf("invalid %s '%s'", f.ColumnName(column), value)
+	fix := fmt.Sprintf("Write the amount like %s, without letters.", f.amountExample())
+	return newRowError(ErrInvalidAmount, column, value, message, fix)
+}
+
+// columnCount returns the minimum number of fields a row needs for format
+func (f *CSVFormat) columnCount() int {
+	count := 0
+	for _, column := range []int{
+		f.DateColumn, f.DescriptionColumn, f.AmountColumn,
+		f.DebitColumn, f.CreditColumn, f.TypeColumn, f.BalanceColumn, f.IDColumn,
+	} {
+		count = max(count, column+1)
+	}
+	return count
+}
+
+// headerMatches reports whether header could be the format's own: an
+// amount column can't be a running balance, and a type column has to be
+// named like one
+func (f *CSVFormat) headerMatches(header []string) bool {
+	name := func(column int) string {
+		if column == NoColumn || column >= len(header) {
+			return ""
+		}
+		return strings.ToLower(strings.TrimSpace(header[column]))
+	}
+	for _, column := range []int{f.AmountColumn, f.DebitColumn, f.CreditColumn} {
+		if name := name(column); strings.HasPrefix(name, "bal") || strings.Contains(name, " bal") {
+			return false
+		}
+	}
+	if f.TypeColumn == NoColumn {
+		return true
+	}
+	return slices.ContainsFunc(typeColumnHeaders, func(word string) bool {
+		return strings.Contains(name(f.TypeColumn), word)
+	})
+}
+
+// typeFromColumn maps a TypeColumn value to a transaction type, returning an
+// empty type when the value isn't one of the configured markers
+func (f *CSVFormat) typeFromColumn(value string) budget.TransactionType {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	debitValues := f.DebitValues
+	if len(debitValues) == 0 {
+		debitValues = DefaultDebitValues
+	}
+	creditValues := f.CreditValues
+	if len(creditValues) == 0 {
+		creditValues = DefaultCreditValues
+	}
+
+	for _, v := range debitValues {
+		if strings.ToLower(v) == value {
+			return budget.Expense
+		}
+	}
+	for _, v := range creditValues {
+		if strings.ToLower(v) == value {
+			return budget.Income
+		}
+	}
+	return ""
+}
+
+// withLocale returns a copy of the format that reads numbers and dates the
+// locale's way, or the format itself when locale is nil
+func (f *CSVFormat) withLocale(locale *Locale) *CSVFormat {
+	if locale == nil || f.JSON {
+		return f
+	}
+	copied := *f
+	copied.Locale = locale
+	// The format's date layout is its bank's, which the locale's replace
+	copied.DateFormat = locale.DateFormats[0]
+	return &copied
+}
+
+// locale returns the format's locale, defaulting to LocaleUS
+func (f *CSVFormat) locale() *Locale {
+	if f.Locale == nil {
+		return &LocaleUS
+	}
+	return f.Locale
+}
+
+// parseOptionalAmount treats an empty cell as zero, as debit/credit and
+// balance columns are often left blank
+func parseOptionalAmount(locale *Locale, amountStr string) (float64, error) {
+	if strings.TrimSpace(amountStr) == "" {
+		return 0, nil
+	}
+	return locale.ParseAmount(amountStr)
 }
 
 func isIncomeDescription(description string) bool {
@@ -259,32 +661,20 @@ func max(a, b int) int {
 	return b
 }
 
-func GetImportPreview(filePath string, format *CSVFormat, maxRows int) ([]PreviewTransaction, error) {
-	result, err := ParseCSV(filePath, format)
-	if err != nil {
-		return nil, err
-	}
-
-	preview := []PreviewTransaction{}
-	count := maxRows
-	if len(result.Transactions) < count {
-		count = len(result.Transactions)
-	}
-
-	categorizer := categorizer.NewCategorizer()
-
-	for i := 0; i < count; i++ {
-		t := result.Transactions[i]
-		category, confidence := categorizer.CategorizeTransaction(t.Description, t.Amount, t.Type)
-
+// GetImportPreview summarizes the first maxRows reviewed rows of result
+func GetImportPreview(result *ImportResult, decisions []RowDecision, maxRows int) []PreviewTransaction {
+	count := min(maxRows, len(decisions))
+	preview := make([]PreviewTransaction, 0, count)
+	for _, d := range decisions[:count] {
+		t := result.Transactions[d.Row]
 		preview = append(preview, PreviewTransaction{
 			Amount:      t.Amount,
 			Description: t.Description,
 			Date:        t.Date.Format("Jan 02"),
-			Category:    category,
-			Confidence:  confidence,
+			Category:    d.Category,
+			Confidence:  d.Confidence,
+			Duplicate:   d.Duplicate,
 		})
 	}
-
-	return preview, nil
+	return preview
 }
@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// formatNamed returns a copy of the common format called name
+func formatNamed(t *testing.T, name string) *CSVFormat {
+	t.Helper()
+	for _, format := range CommonFormats {
+		if format.Name == name {
+			return &format
+		}
+	}
+	t.Fatalf("no format named %q", name)
+	return nil
+}
+
+func TestParseRowColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		row         []string
+		wantAmount  float64
+		wantType    budget.TransactionType
+		wantBalance float64
+		wantErr     RowErrorKind
+	}{
+		{"debit", "Generic (Debit/Credit)", []string{"01/05/2024", "Coffee", "4.50", "", "995.50"}, 4.5, budget.Expense, 995.5, ""},
+		{"credit", "Generic (Debit/Credit)", []string{"01/06/2024", "Paycheck", "", "1,500.00", "2,495.50"}, 1500, budget.Income, 2495.5, ""},
+		{"negative debit", "Generic (Debit/Credit)", []string{"01/05/2024", "Coffee", "-4.50", "", ""}, 4.5, budget.Expense, 0, ""},
+		{"neither debit nor credit", "Generic (Debit/Credit)", []string{"01/05/2024", "Coffee", "", "", "100"}, 0, "", 0, ErrMissingAmount},
+		{"bad balance", "Generic (Debit/Credit)", []string{"01/05/2024", "Coffee", "4.50", "", "n/a"}, 0, "", 0, ErrInvalidBalance},
+		{"type debit", "Generic (Type Column)", []string{"01/05/2024", "Coffee", "4.50", "Debit"}, 4.5, budget.Expense, 0, ""},
+		{"type credit", "Generic (Type Column)", []string{"01/06/2024", "Refund", "-20.00", "CR"}, 20, budget.Income, 0, ""},
+		{"unknown type uses sign", "Generic (Type Column)", []string{"01/05/2024", "Coffee", "-4.50", "pending"}, 4.5, budget.Expense, 0, ""},
+		{"unknown type positive", "Generic (Type Column)", []string{"01/05/2024", "Refund", "4.50", ""}, 4.5, budget.Income, 0, ""},
+		{"missing type column", "Generic (Type Column)", []string{"01/05/2024", "Coffee", "4.50"}, 0, "", 0, ErrMissingColumns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRow(formatNamed(t, tt.format), tt.row)
+			if tt.wantErr != "" {
+				var rowErr *RowError
+				if !errors.As(err, &rowErr) || rowErr.Kind != tt.wantErr {
+					t.Fatalf("parseRow() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRow() error = %v", err)
+			}
+			if got.Amount != tt.wantAmount || got.Type != tt.wantType || got.RunningBalance != tt.wantBalance {
+				t.Errorf("parseRow() = %v %s balance %v, want %v %s balance %v",
+					got.Amount, got.Type, got.RunningBalance, tt.wantAmount, tt.wantType, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestDetectFormatColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   string
+	}{
+		{
+			"type column",
+			"Date,Description,Amount,Type\n01/05/2024,Coffee,4.50,Debit\n01/06/2024,Lunch,12.00,Debit\n01/07/2024,Refund,20.00,Credit\n",
+			"Generic (Type Column)",
+		},
+		{
+			"transaction type header",
+			"Date,Description,Amount,Transaction Type\n01/05/2024,Coffee,4.50,DR\n01/06/2024,Lunch,12.00,DR\n01/07/2024,Refund,20.00,CR\n",
+			"Generic (Type Column)",
+		},
+		{
+			"running balance in the fourth column",
+			"Date,Description,Amount,Running Bal.\n01/05/2024,Coffee,-4.50,995.50\n01/06/2024,Lunch,-12.00,983.50\n01/07/2024,Refund,20.00,1003.50\n",
+			"Bank of America",
+		},
+		{
+			"debit and credit columns",
+			"Date,Description,Debit,Credit,Balance\n01/05/2024,Coffee,4.50,,995.50\n01/06/2024,Lunch,12.00,,983.50\n01/07/2024,Refund,,20.00,1003.50\n",
+			"Generic (Debit/Credit)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat([]byte(tt.sample), false, EncodingUTF8, nil); got.Name != tt.want {
+				t.Errorf("detectFormat() = %s, want %s", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		row        func(i int) string
+		wantFormat string
+	}{
+		{
+			"chase",
+			"Transaction Date,Post Date,Description,Amount\n",
+			func(i int) string {
+				return fmt.Sprintf("01/%02d/2024,01/%02d/2024,Coffee %d,-%d.50\n", i%28+1, i%28+1, i, i%50)
+			},
+			"Chase",
+		},
+		{
+			"semicolon",
+			"Datum;Beschreibung;Betrag\n",
+			func(i int) string { return fmt.Sprintf("%02d.01.2024;Kaffee %d;-1.%03d,50\n", i%28+1, i, i%1000) },
+			"Generic (Semicolon)",
+		},
+		{
+			"no header",
+			"",
+			func(i int) string { return fmt.Sprintf("2024-01-%02d,Coffee %d,-%d.50\n", i%28+1, i, i%50) },
+			"Generic",
+		},
+	}
+
+	const rows = 5000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Well past the detection sample, with one bad row in the middle
+			var sb strings.Builder
+			sb.WriteString(tt.header)
+			for i := range rows {
+				if i == rows/2 {
+					sb.WriteString("not a date,Broken,1\n")
+					continue
+				}
+				sb.WriteString(tt.row(i))
+			}
+			path := filepath.Join(t.TempDir(), "statement.csv")
+			if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var updates []ImportProgress
+			result, err := ParseFile(context.Background(), path, nil, EncodingAuto, nil, func(p ImportProgress) {
+				updates = append(updates, p)
+			})
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+
+			if result.Format.Name != tt.wantFormat {
+				t.Errorf("format = %s, want %s", result.Format.Name, tt.wantFormat)
+			}
+			if result.SuccessCount != rows-1 || len(result.Transactions) != rows-1 {
+				t.Errorf("parsed %d rows (%d transactions), want %d", result.SuccessCount, len(result.Transactions), rows-1)
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Record == nil || result.Errors[0].Row == 0 {
+				t.Errorf("errors = %+v, want the one bad row with its record and row number", result.Errors)
+			}
+			if hash, _ := HashFile(path); result.FileHash != hash {
+				t.Errorf("FileHash = %s, want %s", result.FileHash, hash)
+			}
+
+			// An update every progressInterval rows, then a final one
+			if want := result.TotalRows/progressInterval + 1; len(updates) != want {
+				t.Fatalf("got %d progress updates, want %d", len(updates), want)
+			}
+			for i, p := range updates[:len(updates)-1] {
+				if p.Rows != (i+1)*progressInterval || p.BytesRead == 0 || p.BytesRead > p.TotalBytes {
+					t.Errorf("update %d = %+v", i, p)
+				}
+			}
+			last := updates[len(updates)-1]
+			if last.Rows != result.TotalRows || last.BytesRead != last.TotalBytes || last.Format != tt.wantFormat || last.Fraction() != 1 {
+				t.Errorf("final update = %+v, want every row and byte read", last)
+			}
+		})
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	path := filepath.Join(dir, "statement.csv")
+	if err := os.WriteFile(path, []byte(strings.Repeat("2024-01-05,Coffee,-4.50\n", 500)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		path      string
+		wantStage ImportStage
+	}{
+		{"missing file", context.Background(), filepath.Join(dir, "missing.csv"), StageOpen},
+		{"canceled", ctx, path, StageParse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFile(tt.ctx, tt.path, nil, EncodingAuto, nil, nil)
+			var importErr *ImportError
+			if !errors.As(err, &importErr) || importErr.Stage != tt.wantStage {
+				t.Errorf("ParseFile() error = %v, want a %s error", err, tt.wantStage)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// DuplicateMatch flags an imported row that looks like a transaction we
+// already have
+type DuplicateMatch struct {
+	Index      int    `json:"index"` // index into ImportResult.Transactions
+	ExistingID string `json:"existing_id"`
+	Exact      bool   `json:"exact"`
+	Reason     string `json:"reason"`
+}
+
+// fuzzyDateWindow is how far apart two otherwise identical transactions can
+// be and still count as the same one, to absorb posting-date differences
+// between statement exports
+const fuzzyDateWindow = 24 * time.Hour
+
+// NormalizeDescription lowercases a description and strips punctuation and
+// repeated whitespace, so "AMAZON.COM  *Purchase" matches "Amazon.com Purchase"
+func NormalizeDescription(description string) string {
+	var sb strings.Builder
+	lastSpace := true
+	for _, r := range strings.ToLower(description) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+			lastSpace = false
+		case unicode.IsSpace(r) && !lastSpace:
+			sb.WriteRune(' ')
+			lastSpace = true
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// matchKey identifies a transaction by amount, type, normalized description
+// and account, leaving the date and bank transaction ID to Match
+func matchKey(t budget.Transaction) string {
+	cents := int64(math.Round(t.Amount * 100))
+	return fmt.Sprintf("%d|%s|%s|%s", cents, t.Type, NormalizeDescription(originalDescription(t)), strings.ToLower(t.Account))
+}
+
+// originalDescription prefers the bank's text, so edited descriptions still
+// match the next export of the same statement
+func originalDescription(t budget.Transaction) string {
+	if t.OriginalDescription != "" {
+		return t.OriginalDescription
+	}
+	return t.Description
+}
+
+// DuplicateIndex finds incoming transactions that match known ones. Each
+// known transaction can only be matched once, so two identical coffees on
+// the same day are only flagged if both were already recorded.
+type DuplicateIndex struct {
+	entries      []budget.Transaction
+	used         []bool
+	byExternalID map[string][]int
+	byKey        map[string][]int
+}
+
+func NewDuplicateIndex(existing []budget.Transaction) *DuplicateIndex {
+	index := &DuplicateIndex{
+		byExternalID: make(map[string][]int),
+		byKey:        make(map[string][]int),
+	}
+	for _, t := range existing {
+		index.Add(t)
+	}
+	return index
+}
+
+// Add makes t available for matching by later calls to Match
+func (d *DuplicateIndex) Add(t budget.Transaction) {
+	i := len(d.entries)
+	d.entries = append(d.entries, t)
+	d.used = append(d.used, false)
+
+	if t.ExternalID != "" {
+		idKey := strings.ToLower(t.Account) + "|" + t.ExternalID
+		d.byExternalID[idKey] = append(d.byExternalID[idKey], i)
+	}
+	key := matchKey(t)
+	d.byKey[key] = append(d.byKey[key], i)
+}
+
+// consume marks the known transaction with the given ID as already matched
+func (d *DuplicateIndex) consume(id string) {
+	for i, t := range d.entries {
+		if t.ID == id && !d.used[i] {
+			d.used[i] = true
+			return
+		}
+	}
+}
+
+// Match looks for an unmatched known transaction that t duplicates and, if
+// one is found, consumes it
+func (d *DuplicateIndex) Match(t budget.Transaction) (DuplicateMatch, bool) {
+	// The bank's own ID is authoritative when present
+	if t.ExternalID != "" {
+		idKey := strings.ToLower(t.Account) + "|" + t.ExternalID
+		for _, i := range d.byExternalID[idKey] {
+			if !d.used[i] {
+				d.used[i] = true
+				return DuplicateMatch{ExistingID: d.entries[i].ID, Exact: true, Reason: "same bank transaction ID"}, true
+			}
+		}
+	}
+
+	fuzzy := -1
+	for _, i := range d.byKey[matchKey(t)] {
+		existing := d.entries[i]
+		if d.used[i] {
+			continue
+		}
+		// Different bank IDs mean different transactions, however alike
+		if t.ExternalID != "" && existing.ExternalID != "" && t.ExternalID != existing.ExternalID {
+			continue
+		}
+
+		if sameDay(existing.Date, t.Date) {
+			d.used[i] = true
+			return DuplicateMatch{ExistingID: existing.ID, Exact: true, Reason: "same date, amount and description"}, true
+		}
+		if fuzzy == -1 && absDuration(existing.Date.Sub(t.Date)) <= fuzzyDateWindow {
+			fuzzy = i
+		}
+	}
+
+	if fuzzy != -1 {
+		d.used[fuzzy] = true
+		return DuplicateMatch{ExistingID: d.entries[fuzzy].ID, Exact: false, Reason: "same amount and description, date differs by a day"}, true
+	}
+	return DuplicateMatch{}, false
+}
+
+// MarkDuplicates records which of the result's transactions duplicate
+// existing ones
+func (r *ImportResult) MarkDuplicates(existing []budget.Transaction) {
+	r.markDuplicates(NewDuplicateIndex(existing))
+}
+
+func (r *ImportResult) markDuplicates(index *DuplicateIndex) {
+	r.Duplicates = nil
+	for i, t := range r.Transactions {
+		if match, ok := index.Match(t); ok {
+			match.Index = i
+			r.Duplicates = append(r.Duplicates, match)
+		}
+	}
+	r.duplicateRows = nil
+}
+
+// DuplicateOf returns the duplicate match for the transaction at index i
+func (r *ImportResult) DuplicateOf(i int) (DuplicateMatch, bool) {
+	// Each row has at most one match, so the lookup is stale when the
+	// counts differ
+	if len(r.duplicateRows) != len(r.Duplicates) {
+		r.duplicateRows = make(map[int]int, len(r.Duplicates))
+		for j, match := range r.Duplicates {
+			r.duplicateRows[match.Index] = j
+		}
+	}
+	if j, ok := r.duplicateRows[i]; ok {
+		return r.Duplicates[j], true
+	}
+	return DuplicateMatch{}, false
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
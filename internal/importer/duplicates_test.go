@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDuplicateIndexMatch(t *testing.T) {
+	coffee := budget.Transaction{ID: "old", Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(5)}
+	withID := coffee
+	withID.ExternalID = "tx-1"
+	inAccount := coffee
+	inAccount.Account = "Checking"
+
+	tests := []struct {
+		name      string
+		existing  []budget.Transaction
+		incoming  budget.Transaction
+		wantMatch bool
+		wantExact bool
+	}{
+		{"same day", []budget.Transaction{coffee}, coffee, true, true},
+		{"punctuation differs", []budget.Transaction{coffee}, budget.Transaction{Amount: 4.5, Description: "starbucks 12", Type: budget.Expense, Date: day(5)}, true, true},
+		{"a day apart", []budget.Transaction{coffee}, budget.Transaction{Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(6)}, true, false},
+		{"two days apart", []budget.Transaction{coffee}, budget.Transaction{Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(7)}, false, false},
+		{"different amount", []budget.Transaction{coffee}, budget.Transaction{Amount: 4.75, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(5)}, false, false},
+		{"same bank id", []budget.Transaction{withID}, budget.Transaction{Amount: 99, Description: "other", Type: budget.Expense, Date: day(20), ExternalID: "tx-1"}, true, true},
+		{"different bank ids", []budget.Transaction{withID}, budget.Transaction{Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(5), ExternalID: "tx-2"}, false, false},
+		{"other account", []budget.Transaction{inAccount}, budget.Transaction{Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(5), Account: "Savings"}, false, false},
+		{"same account", []budget.Transaction{inAccount}, budget.Transaction{Amount: 4.5, Description: "STARBUCKS #12", Type: budget.Expense, Date: day(5), Account: "checking"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := NewDuplicateIndex(tt.existing).Match(tt.incoming)
+			if ok != tt.wantMatch {
+				t.Fatalf("Match() matched = %v, want %v", ok, tt.wantMatch)
+			}
+			if ok && match.Exact != tt.wantExact {
+				t.Errorf("Match() exact = %v, want %v", match.Exact, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestDuplicateIndexConsumesMatches(t *testing.T) {
+	coffee := budget.Transaction{ID: "old", Amount: 4.5, Description: "Coffee", Type: budget.Expense, Date: day(5)}
+	index := NewDuplicateIndex([]budget.Transaction{coffee})
+	if _, ok := index.Match(coffee); !ok {
+		t.Fatal("first identical row should match")
+	}
+	if _, ok := index.Match(coffee); ok {
+		t.Error("second identical row matched a transaction already used")
+	}
+}
+
+func TestDuplicateOf(t *testing.T) {
+	coffee := budget.Transaction{ID: "old", Amount: 4.5, Description: "Coffee", Type: budget.Expense, Date: day(5)}
+	lunch := budget.Transaction{Amount: 12, Description: "Lunch", Type: budget.Expense, Date: day(5)}
+	result := &ImportResult{Transactions: []budget.Transaction{lunch, coffee, coffee}}
+	result.MarkDuplicates([]budget.Transaction{coffee})
+
+	for _, tt := range []struct {
+		row  int
+		want bool
+	}{{0, false}, {1, true}, {2, false}, {3, false}} {
+		if _, ok := result.DuplicateOf(tt.row); ok != tt.want {
+			t.Errorf("DuplicateOf(%d) = %v, want %v", tt.row, ok, tt.want)
+		}
+	}
+
+	// A match added after the lookup was built is still found
+	result.Duplicates = append(result.Duplicates, DuplicateMatch{Index: 2, ExistingID: "other"})
+	if match, ok := result.DuplicateOf(2); !ok || match.ExistingID != "other" {
+		t.Errorf("DuplicateOf(2) after append = %+v, %v", match, ok)
+	}
+}
+
+func TestSetAccount(t *testing.T) {
+	result := &ImportResult{Transactions: []budget.Transaction{{Description: "a"}, {Description: "b", Account: "Savings"}}}
+	result.SetAccount("Checking")
+	if result.Account != "Checking" {
+		t.Errorf("Account = %q, want Checking", result.Account)
+	}
+	if got := result.Transactions[0].Account; got != "Checking" {
+		t.Errorf("row without account got %q, want Checking", got)
+	}
+	if got := result.Transactions[1].Account; got != "Savings" {
+		t.Errorf("row with account got %q, want Savings", got)
+	}
+}
@@ -0,0 +1,261 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of an import file
+type Encoding string
+
+const (
+	EncodingAuto        Encoding = "auto"
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingWindows1252 Encoding = "windows-1252"
+)
+
+// Encodings lists the encodings that can be chosen in the import screen
+var Encodings = []Encoding{EncodingAuto, EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingWindows1252}
+
+// sniffSize is how much of a file is inspected to guess its encoding: the
+// same sample format detection looks at
+const sniffSize = sampleSize
+
+// DetectEncoding guesses the encoding of the start of a file from its byte
+// order mark, the layout of NUL bytes, or whether it is valid UTF-8
+func DetectEncoding(sample []byte) Encoding {
+	switch {
+	case len(sample) >= 3 && sample[0] == 0xEF && sample[1] == 0xBB && sample[2] == 0xBF:
+		return EncodingUTF8
+	case len(sample) >= 2 && sample[0] == 0xFF && sample[1] == 0xFE:
+		return EncodingUTF16LE
+	case len(sample) >= 2 && sample[0] == 0xFE && sample[1] == 0xFF:
+		return EncodingUTF16BE
+	}
+
+	// UTF-16 without a BOM: ASCII text has a NUL in every other byte
+	evenZeros, oddZeros := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	if half := len(sample) / 4; half > 0 {
+		if oddZeros > half && evenZeros == 0 {
+			return EncodingUTF16LE
+		}
+		if evenZeros > half && oddZeros == 0 {
+			return EncodingUTF16BE
+		}
+	}
+
+	// The sample may end in the middle of a multi-byte sequence
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax-1 && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// NewDecodingReader wraps r so that it yields UTF-8 without a byte order
+// mark. With EncodingAuto (or an empty encoding) the encoding is sniffed from
+// the start of the stream; the encoding actually used is returned. A stream
+// sniffed as UTF-8 that turns out not to be after the sample is read as
+// Windows-1252 from there on.
+func NewDecodingReader(r io.Reader, enc Encoding) (io.Reader, Encoding, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+
+	sniffed := enc == "" || enc == EncodingAuto
+	if sniffed {
+		sample, err := br.Peek(sniffSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, "", fmt.Errorf("failed to read file: %v", err)
+		}
+		enc = DetectEncoding(sample)
+	}
+
+	switch enc {
+	case EncodingUTF8:
+		if skipBOM(br, []byte{0xEF, 0xBB, 0xBF}) || !sniffed {
+			return br, enc, nil
+		}
+		return &utf8FallbackReader{r: br}, enc, nil
+	case EncodingUTF16LE:
+		skipBOM(br, []byte{0xFF, 0xFE})
+		return &utf16Reader{r: br, order: binary.LittleEndian}, enc, nil
+	case EncodingUTF16BE:
+		skipBOM(br, []byte{0xFE, 0xFF})
+		return &utf16Reader{r: br, order: binary.BigEndian}, enc, nil
+	case EncodingWindows1252:
+		return &windows1252Reader{r: br}, enc, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported encoding '%s'", enc)
+	}
+}
+
+// skipBOM discards the byte order mark, reporting whether there was one
+func skipBOM(br *bufio.Reader, bom []byte) bool {
+	prefix, _ := br.Peek(len(bom))
+	if string(prefix) != string(bom) {
+		return false
+	}
+	br.Discard(len(bom))
+	return true
+}
+
+// utf8FallbackReader passes UTF-8 through until it meets bytes that can't
+// be UTF-8, and reads the rest as Windows-1252. The first accented letter of
+// a Windows-1252 file can come after the sample its encoding was guessed
+// from, and everything before it is ASCII, which both encodings share.
+type utf8FallbackReader struct {
+	r        *bufio.Reader
+	fallback *windows1252Reader
+	pending  []byte
+}
+
+func (u *utf8FallbackReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.pending) > 0 {
+			copied := copy(p[n:], u.pending)
+			u.pending = u.pending[copied:]
+			n += copied
+			continue
+		}
+		if u.fallback != nil {
+			read, err := u.fallback.Read(p[n:])
+			n += read
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+
+		head, err := u.r.Peek(utf8.UTFMax)
+		if len(head) == 0 {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		r, size := utf8.DecodeRune(head)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			u.fallback = &windows1252Reader{r: u.r}
+		default:
+			u.pending = append(u.pending[:0], head[:size]...)
+			u.r.Discard(size)
+		}
+	}
+	return n, nil
+}
+
+// utf16Reader transcodes UTF-16 code units to UTF-8
+type utf16Reader struct {
+	r       *bufio.Reader
+	order   binary.ByteOrder
+	pending []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.pending) > 0 {
+			copied := copy(p[n:], u.pending)
+			u.pending = u.pending[copied:]
+			n += copied
+			continue
+		}
+
+		r, err := u.readRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		u.pending = utf8.AppendRune(u.pending[:0], r)
+	}
+	return n, nil
+}
+
+func (u *utf16Reader) readRune() (rune, error) {
+	unit, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(rune(unit)) {
+		return rune(unit), nil
+	}
+
+	low, err := u.readUnit()
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	return utf16.DecodeRune(rune(unit), rune(low)), nil
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var unit [2]byte
+	if _, err := io.ReadFull(u.r, unit[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	return u.order.Uint16(unit[:]), nil
+}
+
+// windows1252Reader transcodes Windows-1252 bytes to UTF-8
+type windows1252Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+// windows1252High maps bytes 0x80-0x9F, the range where Windows-1252 differs
+// from Latin-1. Unassigned bytes keep their C1 control code point.
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func (w *windows1252Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(w.pending) > 0 {
+			copied := copy(p[n:], w.pending)
+			w.pending = w.pending[copied:]
+			n += copied
+			continue
+		}
+
+		b, err := w.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+
+		r := rune(b)
+		if b >= 0x80 && b <= 0x9F {
+			r = windows1252High[b-0x80]
+		}
+		w.pending = utf8.AppendRune(w.pending[:0], r)
+	}
+	return n, nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample []byte
+		want   Encoding
+	}{
+		{"ascii", []byte("Date,Description,Amount\n"), EncodingUTF8},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFDate"), EncodingUTF8},
+		{"utf-8 accents", []byte("Café Müller"), EncodingUTF8},
+		{"utf-8 cut mid rune", []byte("Caf\xC3"), EncodingUTF8},
+		{"utf-16le bom", []byte("\xFF\xFED\x00a\x00"), EncodingUTF16LE},
+		{"utf-16be bom", []byte("\xFE\xFF\x00D\x00a"), EncodingUTF16BE},
+		{"utf-16le without bom", []byte("D\x00a\x00t\x00e\x00"), EncodingUTF16LE},
+		{"utf-16be without bom", []byte("\x00D\x00a\x00t\x00e"), EncodingUTF16BE},
+		{"windows-1252", []byte("Caf\xE9 M\xFCller"), EncodingWindows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.sample); got != tt.want {
+				t.Errorf("DetectEncoding(%q) = %s, want %s", tt.sample, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDecodingReader(t *testing.T) {
+	// ASCII longer than the sniffed sample, so the file looks like UTF-8
+	// until its first accented letter
+	padding := strings.Repeat("2024-01-15,Coffee,-4.50\n", sniffSize/24+100)
+
+	tests := []struct {
+		name     string
+		input    string
+		enc      Encoding
+		want     string
+		wantUsed Encoding
+	}{
+		{"utf-8 bom stripped", "\xEF\xBB\xBFCafé", EncodingAuto, "Café", EncodingUTF8},
+		{"utf-16le", "\xFF\xFEC\x00a\x00f\x00\xE9\x00", EncodingAuto, "Café", EncodingUTF16LE},
+		{"utf-16be surrogate pair", "\xFE\xFF\xD8\x3D\xDE\x00", EncodingAuto, "😀", EncodingUTF16BE},
+		{"windows-1252", "Caf\xE9 \x80 \x93quoted\x94", EncodingAuto, "Café € “quoted”", EncodingWindows1252},
+		{"windows-1252 after the sample", padding + "Caf\xE9", EncodingAuto, padding + "Café", EncodingUTF8},
+		{"utf-8 after the sample", padding + "Café 😀", EncodingAuto, padding + "Café 😀", EncodingUTF8},
+		{"chosen utf-8 left alone", "Caf\xE9", EncodingUTF8, "Caf\xE9", EncodingUTF8},
+		{"chosen windows-1252", "Caf\xE9", EncodingWindows1252, "Café", EncodingWindows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, used, err := NewDecodingReader(strings.NewReader(tt.input), tt.enc)
+			if err != nil {
+				t.Fatalf("NewDecodingReader unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("reading unexpected error: %v", err)
+			}
+			if used != tt.wantUsed {
+				t.Errorf("encoding = %s, want %s", used, tt.wantUsed)
+			}
+			if !bytes.Equal(got, []byte(tt.want)) {
+				t.Errorf("decoded %q, want %q", truncateForError(got), truncateForError([]byte(tt.want)))
+			}
+		})
+	}
+}
+
+// TestDecodingReaderSmallReads checks a rune is never split across reads
+// too small to hold it
+func TestDecodingReaderSmallReads(t *testing.T) {
+	r, _, err := NewDecodingReader(strings.NewReader("Café 😀"), EncodingAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(got) != "Café 😀" {
+		t.Errorf("decoded %q, want %q", got, "Café 😀")
+	}
+}
+
+func truncateForError(b []byte) []byte {
+	if len(b) > 40 {
+		return b[len(b)-40:]
+	}
+	return b
+}
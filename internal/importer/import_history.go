@@ -1,22 +1,37 @@
 package importer
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
 )
 
 type ImportSession struct {
 	ID         string               `json:"id"`
 	FileName   string               `json:"file_name"`
+	FileHash   string               `json:"file_hash,omitempty"`
 	Source     string               `json:"source"`
-	Status     string               `json:"status"` // "pending", "reviewing", "imported", "error"
+	Encoding   Encoding             `json:"encoding,omitempty"`
+	Account    string               `json:"account,omitempty"`
+	Status     string               `json:"status"` // "pending", "reviewing", "imported", "error", "reverted"
 	TotalCount int                  `json:"total_count"`
 	Imported   int                  `json:"imported"`
 	Skipped    int                  `json:"skipped"`
+	DateFrom   string               `json:"date_from,omitempty"`
+	DateTo     string               `json:"date_to,omitempty"`
 	Errors     []string             `json:"errors,omitempty"`
 	Preview    []PreviewTransaction `json:"preview,omitempty"`
+	Decisions  []RowDecision        `json:"decisions,omitempty"`
+	Files      []FileSummary        `json:"files,omitempty"` // set for batch imports
 	Timestamp  string               `json:"timestamp"`
+	RevertedAt string               `json:"reverted_at,omitempty"`
 }
 
 type PreviewTransaction struct {
@@ -25,6 +40,7 @@ type PreviewTransaction struct {
 	Date        string  `json:"date"`
 	Category    string  `json:"category"`
 	Confidence  float64 `json:"confidence"`
+	Duplicate   bool    `json:"duplicate,omitempty"`
 }
 
 type ImportHistory struct {
@@ -66,11 +82,61 @@ func (h *ImportHistory) Save() error {
 
 func (h *ImportHistory) AddSession(session ImportSession) {
 	h.Sessions = append(h.Sessions, session)
+}
+
+// Search returns the sessions whose file name, format, status, file hash or
+// timestamp contains query, newest first
+func (h *ImportHistory) Search(query string) []*ImportSession {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var matches []*ImportSession
+	for i := len(h.Sessions) - 1; i >= 0; i-- {
+		session := &h.Sessions[i]
+		fields := []string{session.FileName, session.Source, session.Status, session.FileHash, session.Timestamp}
+		for _, file := range session.Files {
+			fields = append(fields, file.Path, file.Hash)
+		}
+		if query == "" || strings.Contains(strings.ToLower(strings.Join(fields, " ")), query) {
+			matches = append(matches, session)
+		}
+	}
+	return matches
+}
+
+// HasFile reports whether a file with this hash was imported before
+func (h *ImportHistory) HasFile(hash string) bool {
+	for _, session := range h.Sessions {
+		if hash != "" && session.FileHash == hash {
+			return true
+		}
+		for _, file := range session.Files {
+			if hash != "" && file.Hash == hash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *ImportHistory) FindSession(id string) *ImportSession {
+	for i := range h.Sessions {
+		if h.Sessions[i].ID == id {
+			return &h.Sessions[i]
+		}
+	}
+	return nil
+}
 
-	// Keep only last 10 sessions
-	if len(h.Sessions) > 10 {
-		h.Sessions = h.Sessions[len(h.Sessions)-10:]
+// MarkReverted flags an imported session as rolled back. Only sessions in
+// the "imported" state can be reverted.
+func (h *ImportHistory) MarkReverted(id string) bool {
+	session := h.FindSession(id)
+	if session == nil || session.Status != "imported" {
+		return false
 	}
+	session.Status = "reverted"
+	session.RevertedAt = time.Now().Format("2006-01-02 15:04:05")
+	return true
 }
 
 func (h *ImportHistory) GetLastSession() *ImportSession {
@@ -79,3 +145,39 @@ func (h *ImportHistory) GetLastSession() *ImportSession {
 	}
 	return &h.Sessions[len(h.Sessions)-1]
 }
+
+// SetDateRange records the span of transaction dates an import covers
+func (s *ImportSession) SetDateRange(transactions []budget.Transaction) {
+	s.DateFrom, s.DateTo = "", ""
+	if len(transactions) == 0 {
+		return
+	}
+
+	from, to := transactions[0].Date, transactions[0].Date
+	for _, t := range transactions[1:] {
+		if t.Date.Before(from) {
+			from = t.Date
+		}
+		if t.Date.After(to) {
+			to = t.Date
+		}
+	}
+	s.DateFrom = from.Format("2006-01-02")
+	s.DateTo = to.Format("2006-01-02")
+}
+
+// HashFile returns the SHA-256 of a file's contents, so the same statement
+// can be recognized when it is imported again
+func HashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,265 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// JSONFormat reads transactions written by scripts and other tools, either a
+// JSON array of JSONTransaction objects or one object per line (NDJSON)
+var JSONFormat = CSVFormat{
+	Name:              "JSON",
+	DateColumn:        NoColumn,
+	DescriptionColumn: NoColumn,
+	AmountColumn:      NoColumn,
+	DebitColumn:       NoColumn,
+	CreditColumn:      NoColumn,
+	TypeColumn:        NoColumn,
+	BalanceColumn:     NoColumn,
+	IDColumn:          NoColumn,
+	JSON:              true,
+}
+
+// Formats lists every format a file can be imported with
+func Formats() []CSVFormat {
+	return append(slices.Clip(CommonFormats), JSONFormat)
+}
+
+// JSONTransaction is one transaction of the structured import format. Date,
+// description and amount are required. A negative amount is an expense
+// unless Type says otherwise.
+type JSONTransaction struct {
+	Date        string     `json:"date"` // 2006-01-02 or RFC 3339
+	Description string     `json:"description"`
+	Amount      JSONAmount `json:"amount"`         // a number, or a string holding one
+	Type        string     `json:"type,omitempty"` // "income" or "expense"
+	Category    string     `json:"category,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Account     string     `json:"account,omitempty"`
+	ExternalID  string     `json:"external_id,omitempty"`
+	Balance     JSONAmount `json:"balance,omitempty"`
+}
+
+// JSONAmount is an amount written either as a JSON number or as a string,
+// kept as written until it is parsed. null counts as left out.
+type JSONAmount string
+
+func (a *JSONAmount) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*a = ""
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*a = JSONAmount(strings.TrimSpace(text))
+		return nil
+	}
+	*a = JSONAmount(data)
+	return nil
+}
+
+func (a JSONAmount) float64() (float64, error) {
+	return strconv.ParseFloat(string(a), 64)
+}
+
+// maxJSONLine is the longest NDJSON line that can be read
+const maxJSONLine = 1024 * 1024
+
+// looksLikeJSON reports whether a sample of a file starts like JSON rather
+// than delimited text
+func looksLikeJSON(sample []byte) bool {
+	sample = bytes.TrimLeft(sample, " \t\r\n")
+	return len(sample) > 0 && (sample[0] == '[' || sample[0] == '{')
+}
+
+// parseJSONFile is ParseFile for JSONFormat
+func parseJSONFile(ctx context.Context, filePath string, r *bufio.Reader, counter *countingReader, encoding Encoding, progress ImportProgress, onProgress func(ImportProgress)) (*ImportResult, error) {
+	result := &ImportResult{
+		Transactions: []budget.Transaction{},
+		Format:       JSONFormat,
+		Encoding:     encoding,
+		Errors:       []RowError{},
+	}
+
+	err := parseJSON(ctx, r, result, func(row int) {
+		if onProgress != nil && row%progressInterval == 0 {
+			progress.BytesRead = counter.n
+			progress.Rows = row
+			onProgress(progress)
+		}
+	})
+	if err != nil {
+		return nil, &ImportError{Stage: StageParse, File: filePath, Err: err}
+	}
+
+	if onProgress != nil {
+		progress.BytesRead = counter.n
+		progress.Rows = result.TotalRows
+		onProgress(progress)
+	}
+
+	io.Copy(io.Discard, counter)
+	result.FileHash = hex.EncodeToString(counter.hash.Sum(nil))
+	return result, nil
+}
+
+// parseJSON reads a JSON array or NDJSON stream into result. Rows that
+// aren't valid become row errors; only a broken array stops the import.
+func parseJSON(ctx context.Context, r *bufio.Reader, result *ImportResult, onRow func(row int)) error {
+	start, err := r.Peek(1)
+	for err == nil && (start[0] == ' ' || start[0] == '\t' || start[0] == '\r' || start[0] == '\n') {
+		r.ReadByte()
+		start, err = r.Peek(1)
+	}
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	addRow := func(raw string) {
+		result.TotalRows++
+		row := result.TotalRows
+		transaction, rowErr := parseJSONTransaction(raw)
+		if rowErr != nil {
+			rowErr.Row = row
+			rowErr.Record = []string{raw}
+			result.Errors = append(result.Errors, *rowErr)
+		} else {
+			result.Transactions = append(result.Transactions, transaction)
+			result.SuccessCount++
+		}
+		onRow(row)
+	}
+
+	if start[0] == '[' {
+		decoder := json.NewDecoder(r)
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		for decoder.More() {
+			if result.TotalRows%100 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return err
+			}
+			addRow(string(raw))
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLine)
+	for scanner.Scan() {
+		if result.TotalRows%100 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		addRow(line)
+	}
+	return scanner.Err()
+}
+
+// parseJSONTransaction turns one JSON object into a transaction
+func parseJSONTransaction(raw string) (budget.Transaction, *RowError) {
+	var row JSONTransaction
+	if err := json.Unmarshal([]byte(raw), &row); err != nil {
+		return budget.Transaction{}, newRowError(ErrMalformedLine, NoColumn, "", err.Error(),
+			`Write each transaction as an object such as {"date": "2024-01-31", "description": "Coffee", "amount": -4.50}.`)
+	}
+
+	date, err := parseJSONDate(row.Date)
+	if err != nil {
+		return budget.Transaction{}, newRowError(ErrInvalidDate, NoColumn, row.Date,
+			fmt.Sprintf("invalid date '%s'", row.Date), "Write the date like 2024-01-31.")
+	}
+
+	description := strings.TrimSpace(row.Description)
+	if description == "" {
+		return budget.Transaction{}, newRowError(ErrMissingDescription, NoColumn, "",
+			"missing description", `Add a "description" field with the payee or memo.`)
+	}
+
+	if row.Amount == "" {
+		return budget.Transaction{}, newRowError(ErrMissingAmount, NoColumn, "",
+			"missing amount", `Add an "amount" field.`)
+	}
+	amount, err := row.Amount.float64()
+	if err != nil {
+		return budget.Transaction{}, newRowError(ErrInvalidAmount, NoColumn, string(row.Amount),
+			fmt.Sprintf("invalid amount '%s'", row.Amount), "Write the amount as a number like -1234.56.")
+	}
+
+	var transType budget.TransactionType
+	switch strings.ToLower(strings.TrimSpace(row.Type)) {
+	case "income":
+		transType = budget.Income
+	case "expense":
+		transType = budget.Expense
+	case "":
+		transType = budget.Income
+		if amount < 0 {
+			transType = budget.Expense
+		}
+	default:
+		return budget.Transaction{}, newRowError(ErrInvalidType, NoColumn, row.Type,
+			fmt.Sprintf("unknown type '%s'", row.Type), `Set "type" to "income" or "expense", or leave it out.`)
+	}
+
+	category := strings.TrimSpace(row.Category)
+	if category == "" {
+		category = "Uncategorized"
+	}
+
+	transaction := budget.Transaction{
+		ID:                  budget.GenerateID(),
+		Amount:              math.Abs(amount),
+		Description:         description,
+		OriginalDescription: description,
+		Category:            category,
+		Type:                transType,
+		Date:                date,
+		ImportSource:        JSONFormat.Name,
+		IsImported:          true,
+		Account:             strings.TrimSpace(row.Account),
+		ExternalID:          strings.TrimSpace(row.ExternalID),
+		Tags:                row.Tags,
+	}
+
+	if row.Balance != "" {
+		balance, err := row.Balance.float64()
+		if err != nil {
+			return budget.Transaction{}, newRowError(ErrInvalidBalance, NoColumn, string(row.Balance),
+				fmt.Sprintf("invalid balance '%s'", row.Balance), "Write the balance as a number like 1234.56.")
+		}
+		transaction.RunningBalance = balance
+	}
+
+	return transaction, nil
+}
+
+func parseJSONDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestParseJSONTransaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantKind    RowErrorKind
+		wantAmount  float64
+		wantType    budget.TransactionType
+		wantBalance float64
+	}{
+		{"expense", `{"date": "2024-01-05", "description": "Coffee", "amount": -4.50}`, "", 4.5, budget.Expense, 0},
+		{"income", `{"date": "2024-01-31T09:00:00Z", "description": "Payroll", "amount": 2500}`, "", 2500, budget.Income, 0},
+		{"string amount", `{"date": "2024-01-05", "description": "Coffee", "amount": " -4.50 "}`, "", 4.5, budget.Expense, 0},
+		{"type overrides sign", `{"date": "2024-01-05", "description": "Refund", "amount": -10, "type": "Income"}`, "", 10, budget.Income, 0},
+		{"balance", `{"date": "2024-01-05", "description": "Coffee", "amount": -4.50, "balance": "120.25"}`, "", 4.5, budget.Expense, 120.25},
+		{"null balance", `{"date": "2024-01-05", "description": "Coffee", "amount": -4.50, "balance": null}`, "", 4.5, budget.Expense, 0},
+		{"not an object", `[1, 2]`, ErrMalformedLine, 0, "", 0},
+		{"bad date", `{"date": "05/01/2024", "description": "Coffee", "amount": -4.50}`, ErrInvalidDate, 0, "", 0},
+		{"missing description", `{"date": "2024-01-05", "amount": -4.50}`, ErrMissingDescription, 0, "", 0},
+		{"blank description", `{"date": "2024-01-05", "description": "  ", "amount": -4.50}`, ErrMissingDescription, 0, "", 0},
+		{"missing amount", `{"date": "2024-01-05", "description": "Coffee"}`, ErrMissingAmount, 0, "", 0},
+		{"null amount", `{"date": "2024-01-05", "description": "Coffee", "amount": null}`, ErrMissingAmount, 0, "", 0},
+		{"bad amount", `{"date": "2024-01-05", "description": "Coffee", "amount": "four"}`, ErrInvalidAmount, 0, "", 0},
+		{"unknown type", `{"date": "2024-01-05", "description": "Coffee", "amount": -4.50, "type": "debit"}`, ErrInvalidType, 0, "", 0},
+		{"bad balance", `{"date": "2024-01-05", "description": "Coffee", "amount": -4.50, "balance": "lots"}`, ErrInvalidBalance, 0, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rowErr := parseJSONTransaction(tt.raw)
+			if tt.wantKind != "" {
+				if rowErr == nil {
+					t.Fatalf("parseJSONTransaction() = %+v, want %s error", got, tt.wantKind)
+				}
+				if rowErr.Kind != tt.wantKind {
+					t.Errorf("error kind = %s, want %s (%s)", rowErr.Kind, tt.wantKind, rowErr.Message)
+				}
+				return
+			}
+			if rowErr != nil {
+				t.Fatalf("parseJSONTransaction() error = %v", rowErr)
+			}
+			if got.Amount != tt.wantAmount || got.Type != tt.wantType || got.RunningBalance != tt.wantBalance {
+				t.Errorf("got amount %v %s balance %v, want %v %s balance %v",
+					got.Amount, got.Type, got.RunningBalance, tt.wantAmount, tt.wantType, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantErr    bool
+		wantRows   int
+		wantErrors int
+		wantParsed int
+	}{
+		{"array", `[{"date": "2024-01-05", "description": "Coffee", "amount": -4.5}, {"date": "bad", "description": "Tea", "amount": -3}]`, false, 2, 1, 1},
+		{"ndjson", "{\"date\": \"2024-01-05\", \"description\": \"Coffee\", \"amount\": -4.5}\n\n{\"date\": \"2024-01-06\", \"description\": \"Tea\", \"amount\": -3}\n", false, 2, 0, 2},
+		{"ndjson bad line", "{\"date\": \"2024-01-05\", \"description\": \"Coffee\", \"amount\": -4.5}\n{broken\n", false, 2, 1, 1},
+		{"empty", "  \n", false, 0, 0, 0},
+		{"empty array", "[]", false, 0, 0, 0},
+		{"broken array", `[{"date": "2024-01-05", "description": "Coffee", "amount": -4.5},`, true, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ImportResult{}
+			err := parseJSON(context.Background(), bufio.NewReader(strings.NewReader(tt.input)), result, func(int) {})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.TotalRows != tt.wantRows || len(result.Errors) != tt.wantErrors || len(result.Transactions) != tt.wantParsed {
+				t.Errorf("got %d rows, %d errors, %d transactions; want %d, %d, %d",
+					result.TotalRows, len(result.Errors), len(result.Transactions), tt.wantRows, tt.wantErrors, tt.wantParsed)
+			}
+		})
+	}
+}
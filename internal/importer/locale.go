@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NegativeStyle describes how a bank writes negative amounts, in addition
+// to a leading minus sign which is always accepted
+type NegativeStyle int
+
+const (
+	NegativeLeadingMinus  NegativeStyle = iota // -45.00
+	NegativeParentheses                        // (45.00)
+	NegativeTrailingMinus                      // 45.00-
+)
+
+// Locale holds the number and date conventions used by a bank export
+type Locale struct {
+	Name             string
+	DecimalSeparator rune
+	GroupSeparators  []rune
+	NegativeStyle    NegativeStyle
+	CurrencySymbols  []string
+	DateFormats      []string
+}
+
+var (
+	LocaleUS = Locale{
+		Name:             "en-US",
+		DecimalSeparator: '.',
+		GroupSeparators:  []rune{','},
+		NegativeStyle:    NegativeParentheses,
+		CurrencySymbols:  []string{"US$", "USD", "$"},
+		DateFormats:      []string{"01/02/2006", "1/2/2006", "01/02/06", "2006-01-02"},
+	}
+	LocaleUK = Locale{
+		Name:             "en-GB",
+		DecimalSeparator: '.',
+		GroupSeparators:  []rune{','},
+		NegativeStyle:    NegativeParentheses,
+		CurrencySymbols:  []string{"GBP", "£"},
+		DateFormats:      []string{"02/01/2006", "2/1/2006", "02/01/06", "2006-01-02", "02 Jan 2006"},
+	}
+	LocaleDE = Locale{
+		Name:             "de-DE",
+		DecimalSeparator: ',',
+		GroupSeparators:  []rune{'.'},
+		NegativeStyle:    NegativeTrailingMinus,
+		CurrencySymbols:  []string{"EUR", "€"},
+		DateFormats:      []string{"02.01.2006", "2.1.2006", "02.01.06", "2006-01-02"},
+	}
+	LocaleFR = Locale{
+		Name:             "fr-FR",
+		DecimalSeparator: ',',
+		GroupSeparators:  []rune{' ', '\u00a0', '\u202f'},
+		NegativeStyle:    NegativeLeadingMinus,
+		CurrencySymbols:  []string{"EUR", "€"},
+		DateFormats:      []string{"02/01/2006", "2/1/2006", "02/01/06", "2006-01-02"},
+	}
+	LocaleCH = Locale{
+		Name:             "de-CH",
+		DecimalSeparator: '.',
+		GroupSeparators:  []rune{'\'', '’'},
+		NegativeStyle:    NegativeLeadingMinus,
+		CurrencySymbols:  []string{"CHF", "Fr."},
+		DateFormats:      []string{"02.01.2006", "2.1.2006", "02.01.06", "2006-01-02"},
+	}
+)
+
+// Locales lists the built-in locale profiles
+var Locales = []*Locale{&LocaleUS, &LocaleUK, &LocaleDE, &LocaleFR, &LocaleCH}
+
+// LookupLocale returns the built-in locale with the given name
+func LookupLocale(name string) (*Locale, bool) {
+	for _, locale := range Locales {
+		if strings.EqualFold(locale.Name, name) {
+			return locale, true
+		}
+	}
+	return nil, false
+}
+
+// ParseAmount parses an amount written in this locale, e.g. "(1,234.56)"
+// for en-US or "1.234,56-" for de-DE
+func (l *Locale) ParseAmount(amountStr string) (float64, error) {
+	s := strings.TrimSpace(amountStr)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	negative := false
+	if l.NegativeStyle == NegativeParentheses && strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	s = l.stripCurrency(s)
+
+	switch {
+	case strings.HasPrefix(s, "-"), strings.HasPrefix(s, "−"):
+		negative = !negative
+		s = strings.TrimLeft(s, "-−")
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case l.NegativeStyle == NegativeTrailingMinus && strings.HasSuffix(s, "-"):
+		negative = !negative
+		s = s[:len(s)-1]
+	}
+
+	// The sign may come before the currency symbol, as in "-$45.00"
+	s = l.stripCurrency(s)
+
+	intPart, fracPart, hasFraction := strings.Cut(s, string(l.DecimalSeparator))
+	if hasFraction && !isDigits(fracPart) {
+		return 0, fmt.Errorf("invalid amount '%s' for locale %s", amountStr, l.Name)
+	}
+
+	groups := strings.FieldsFunc(intPart, l.isGroupSeparator)
+	if len(groups) == 0 && !hasFraction {
+		return 0, fmt.Errorf("invalid amount '%s' for locale %s", amountStr, l.Name)
+	}
+	for i, group := range groups {
+		// Digit groups after the first must be exactly three digits, which
+		// catches files written in a different locale, e.g. "1.234,56" read as en-US
+		if !isDigits(group) || (i > 0 && len(group) != 3) {
+			return 0, fmt.Errorf("invalid amount '%s' for locale %s", amountStr, l.Name)
+		}
+	}
+
+	normalized := strings.Join(groups, "")
+	if normalized == "" {
+		normalized = "0"
+	}
+	if hasFraction {
+		normalized += "." + fracPart
+	}
+
+	amount, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount '%s' for locale %s", amountStr, l.Name)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// ParseDate tries the preferred layout first and then each of the locale's
+// date layouts
+func (l *Locale) ParseDate(dateStr, preferred string) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
+
+	layouts := l.DateFormats
+	if preferred != "" {
+		layouts = append([]string{preferred}, layouts...)
+	}
+
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, dateStr); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", dateStr)
+}
+
+func (l *Locale) stripCurrency(s string) string {
+	// Check longer symbols first so "US$" isn't left as "US"
+	symbols := make([]string, len(l.CurrencySymbols))
+	copy(symbols, l.CurrencySymbols)
+	sort.Slice(symbols, func(i, j int) bool {
+		return len(symbols[i]) > len(symbols[j])
+	})
+
+	for _, symbol := range symbols {
+		if len(s) >= len(symbol) && strings.EqualFold(s[:len(symbol)], symbol) {
+			s = s[len(symbol):]
+			break
+		}
+		if len(s) >= len(symbol) && strings.EqualFold(s[len(s)-len(symbol):], symbol) {
+			s = s[:len(s)-len(symbol)]
+			break
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+func (l *Locale) isGroupSeparator(r rune) bool {
+	for _, sep := range l.GroupSeparators {
+		if r == sep {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestLocaleParseAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  *Locale
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"us plain", &LocaleUS, "45.00", 45, false},
+		{"us grouped", &LocaleUS, "1,234.56", 1234.56, false},
+		{"us currency", &LocaleUS, "$1,234.56", 1234.56, false},
+		{"us negative currency", &LocaleUS, "-$45.00", -45, false},
+		{"us currency negative", &LocaleUS, "$-45.00", -45, false},
+		{"us accounting", &LocaleUS, "(45.00)", -45, false},
+		{"us accounting currency", &LocaleUS, "($1,045.00)", -1045, false},
+		{"us currency code", &LocaleUS, "USD 12.50", 12.5, false},
+		{"us explicit plus", &LocaleUS, "+12.50", 12.5, false},
+		{"us no fraction", &LocaleUS, "1,000", 1000, false},
+		{"us fraction only", &LocaleUS, ".99", 0.99, false},
+		{"us rejects de format", &LocaleUS, "1.234,56", 0, true},
+		{"us rejects bad grouping", &LocaleUS, "12,34.00", 0, true},
+		{"us rejects text", &LocaleUS, "Debit", 0, true},
+		{"us rejects empty", &LocaleUS, "  ", 0, true},
+		{"uk pound", &LocaleUK, "£1,200.00", 1200, false},
+		{"de grouped", &LocaleDE, "1.234,56", 1234.56, false},
+		{"de euro suffix", &LocaleDE, "1.234,56 €", 1234.56, false},
+		{"de trailing minus", &LocaleDE, "45,00-", -45, false},
+		{"de leading minus", &LocaleDE, "-45,00", -45, false},
+		{"de parentheses not negative", &LocaleDE, "(45,00)", 0, true},
+		{"fr space grouping", &LocaleFR, "1 234,56", 1234.56, false},
+		{"fr nbsp grouping", &LocaleFR, "1 234,56 €", 1234.56, false},
+		{"fr negative", &LocaleFR, "-12,30", -12.3, false},
+		{"ch apostrophe grouping", &LocaleCH, "CHF 1'234.50", 1234.5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.locale.ParseAmount(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseAmount(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleParseDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		locale    *Locale
+		input     string
+		preferred string
+		want      time.Time
+		wantErr   bool
+	}{
+		{"us preferred", &LocaleUS, "12/01/2024", "01/02/2006", date(2024, 12, 1), false},
+		{"us short", &LocaleUS, "3/7/2024", "01/02/2006", date(2024, 3, 7), false},
+		{"us two digit year", &LocaleUS, "03/07/24", "", date(2024, 3, 7), false},
+		{"us iso fallback", &LocaleUS, "2024-03-07", "01/02/2006", date(2024, 3, 7), false},
+		{"uk day first", &LocaleUK, "07/03/2024", "", date(2024, 3, 7), false},
+		{"uk month name", &LocaleUK, "07 Mar 2024", "", date(2024, 3, 7), false},
+		{"de dotted", &LocaleDE, "07.03.2024", "", date(2024, 3, 7), false},
+		{"de short", &LocaleDE, "7.3.2024", "", date(2024, 3, 7), false},
+		{"preferred wins over locale", &LocaleUK, "03/07/2024", "01/02/2006", date(2024, 3, 7), false},
+		{"invalid", &LocaleUS, "not a date", "", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.locale.ParseDate(tt.input, tt.preferred)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDate(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q) unexpected error: %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRowLocale(t *testing.T) {
+	format := CSVFormat{
+		Name:              "Test",
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		AmountColumn:      2,
+		DebitColumn:       NoColumn,
+		CreditColumn:      NoColumn,
+		TypeColumn:        NoColumn,
+		BalanceColumn:     NoColumn,
+		IDColumn:          NoColumn,
+		DateFormat:        "02.01.2006",
+		AmountIsNegative:  true,
+		Locale:            &LocaleDE,
+	}
+
+	tests := []struct {
+		name     string
+		row      []string
+		want     float64
+		wantType budget.TransactionType
+	}{
+		{"expense", []string{"07.03.2024", "Bäckerei", "1.234,56-"}, 1234.56, budget.Expense},
+		{"income", []string{"07.03.2024", "Gehalt", "2.500,00"}, 2500, budget.Income},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRow(&format, tt.row)
+			if err != nil {
+				t.Fatalf("parseRow(%q) unexpected error: %v", tt.row, err)
+			}
+			if got.Amount != tt.want || got.Type != tt.wantType {
+				t.Errorf("parseRow(%q) = %v %s, want %v %s", tt.row, got.Amount, got.Type, tt.want, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestParseFileLocaleOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statement.csv")
+	content := "Date,Description,Amount\n05/01/2024,Coffee,-4.50\n13/01/2024,Lunch,-12.00\n20/01/2024,Pay,1500.00\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bankOfAmerica := formatNamed(t, "Bank of America")
+
+	tests := []struct {
+		name     string
+		format   *CSVFormat
+		locale   *Locale
+		wantRows int
+		wantDate time.Time
+	}{
+		{"detected in the chosen locale", nil, &LocaleUK, 3, date(2024, time.January, 5)},
+		{"given format in the chosen locale", bankOfAmerica, &LocaleUK, 3, date(2024, time.January, 5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseFile(context.Background(), path, tt.format, EncodingAuto, tt.locale, nil)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			if len(result.Transactions) != tt.wantRows {
+				t.Fatalf("parsed %d rows, want %d", len(result.Transactions), tt.wantRows)
+			}
+			if got := result.Transactions[0].Date; !got.Equal(tt.wantDate) {
+				t.Errorf("first date = %s, want %s", got.Format("2006-01-02"), tt.wantDate.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want *Locale
+	}{{"en-GB", &LocaleUK}, {"DE-de", &LocaleDE}, {"xx", nil}, {"", nil}} {
+		if got, _ := LookupLocale(tt.name); got != tt.want {
+			t.Errorf("LookupLocale(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// ImportStage is a step of the import pipeline
+type ImportStage string
+
+const (
+	StageOpen       ImportStage = "open"
+	StageDetect     ImportStage = "detect"
+	StageParse      ImportStage = "parse"
+	StageCategorize ImportStage = "categorize"
+)
+
+// progressInterval is how many rows are handled between progress updates
+const progressInterval = 1000
+
+// ImportProgress reports how far an import has got
+type ImportProgress struct {
+	Stage      ImportStage
+	File       string
+	Format     string
+	BytesRead  int64
+	TotalBytes int64
+	Rows       int
+	// Done counts rows categorized, or files parsed in a batch import
+	Done  int
+	Total int
+}
+
+// Fraction is the share of the current stage that is done, between 0 and 1
+func (p ImportProgress) Fraction() float64 {
+	switch {
+	case p.Total > 0:
+		return math.Min(float64(p.Done)/float64(p.Total), 1)
+	case p.TotalBytes > 0:
+		return math.Min(float64(p.BytesRead)/float64(p.TotalBytes), 1)
+	default:
+		return 0
+	}
+}
+
+// ImportError is an import that failed, with the file and stage it failed at
+type ImportError struct {
+	Stage ImportStage
+	File  string
+	Err   error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%s failed for %s: %v", e.Stage, filepath.Base(e.File), e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Canceled reports whether the import was stopped by the user
+func (e *ImportError) Canceled() bool {
+	return errors.Is(e.Err, context.Canceled)
+}
+
+// Hint suggests what to try next
+func (e *ImportError) Hint() string {
+	switch {
+	case errors.Is(e.Err, os.ErrNotExist):
+		return "Check the file path; the file may have been moved or deleted."
+	case errors.Is(e.Err, os.ErrPermission):
+		return "The file isn't readable. Check its permissions."
+	case e.Stage == StageDetect:
+		return "Try forcing an encoding with Ctrl+E on the import screen."
+	case e.Stage == StageParse:
+		return "Check that the file is a CSV export, or re-run it with another format from the import history."
+	default:
+		return ""
+	}
+}
+
+// PrepareImport runs a file through format detection (when format is nil),
+// parsing, duplicate detection against existing and categorization, and
+// returns a session ready for review. A locale, when not nil, replaces the
+// format's own. Rows the file doesn't name an account for get account. It
+// stops early when ctx is canceled. onProgress, when not nil, receives
+// progress updates.
+func PrepareImport(ctx context.Context, filePath string, format *CSVFormat, enc Encoding, locale *Locale, account string, existing []budget.Transaction, c *categorizer.Categorizer, onProgress func(ImportProgress)) (*ImportSession, *ImportResult, error) {
+	session := &ImportSession{
+		ID:         budget.GenerateID(),
+		FileName:   filePath,
+		Source:     "Unknown",
+		Status:     "reviewing",
+		TotalCount: 0,
+		Imported:   0,
+		Skipped:    0,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	// Detect format and parse in one pass
+	result, err := ParseFile(ctx, filePath, format, enc, locale, onProgress)
+	if err != nil {
+		return session, nil, err
+	}
+
+	result.SetAccount(account)
+	result.MarkDuplicates(existing)
+	session.Account = account
+	session.FileHash = result.FileHash
+	session.Source = result.Format.Name
+	session.TotalCount = len(result.Transactions)
+	session.Encoding = result.Encoding
+	session.Errors = result.ErrorMessages()
+	session.SetDateRange(result.Transactions)
+
+	session.Decisions, err = categorize(ctx, result, c, filePath, onProgress)
+	if err != nil {
+		return session, nil, err
+	}
+	session.Preview = GetImportPreview(result, session.Decisions, 10)
+
+	return session, result, nil
+}
+
+// categorize runs the review's categorization stage with progress updates
+func categorize(ctx context.Context, result *ImportResult, c *categorizer.Categorizer, file string, onProgress func(ImportProgress)) ([]RowDecision, error) {
+	progress := ImportProgress{Stage: StageCategorize, File: file, Format: result.Format.Name, Total: len(result.Transactions)}
+
+	var report func(done int)
+	if onProgress != nil {
+		onProgress(progress)
+		report = func(done int) {
+			progress.Done = done
+			onProgress(progress)
+		}
+	}
+
+	decisions, err := newReview(ctx, result, c, report)
+	if err != nil {
+		return nil, &ImportError{Stage: StageCategorize, File: file, Err: err}
+	}
+	return decisions, nil
+}
+
+// ApplyImport adds the session's accepted rows to b, tagged with the session
+// ID, and marks the session imported. It returns how many rows were added.
+func ApplyImport(b *budget.Budget, session *ImportSession, result *ImportResult) int {
+	accepted := AcceptedTransactions(result, session.Decisions)
+	for i := range accepted {
+		accepted[i].ImportSessionID = session.ID
+	}
+	b.Transactions = append(b.Transactions, accepted...)
+
+	session.Status = "imported"
+	session.Imported = len(accepted)
+	session.Skipped = len(result.Transactions) - len(accepted)
+	return len(accepted)
+}
+
+// FullyConfident reports whether a prepared import could go in without
+// review: it parsed cleanly, has no likely duplicates and every row is
+// categorized with at least threshold confidence
+func (s *ImportSession) FullyConfident(result *ImportResult, threshold float64) bool {
+	if len(result.Errors) > 0 || len(result.Duplicates) > 0 || len(s.Decisions) == 0 {
+		return false
+	}
+	for _, d := range s.Decisions {
+		if d.Action != RowAccept || d.Confidence < threshold {
+			return false
+		}
+	}
+	return true
+}
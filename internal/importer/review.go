@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+type RowAction string
+
+const (
+	RowAccept RowAction = "accept"
+	RowReject RowAction = "reject"
+)
+
+// RowDecision records what was decided for one imported row during review
+type RowDecision struct {
+	Row               int       `json:"row"` // index into ImportResult.Transactions
+	Action            RowAction `json:"action"`
+	Description       string    `json:"description"`
+	SuggestedCategory string    `json:"suggested_category"`
+	Category          string    `json:"category"`
+	Confidence        float64   `json:"confidence"`
+	IsTransfer        bool      `json:"is_transfer,omitempty"`
+	Duplicate         bool      `json:"duplicate,omitempty"`
+	// Actions are those of the rule that suggested the category, applied
+	// unless the category is changed
+	Actions *categorizer.RuleActions `json:"actions,omitempty"`
+	// Rule identifies the rule that suggested the category and MatchedBy
+	// how it matched, to keep score of how often it is right
+	Rule      string `json:"rule,omitempty"`
+	MatchedBy string `json:"matched_by,omitempty"`
+}
+
+// Edited reports whether the user changed the row from what was suggested
+func (d RowDecision) Edited() bool {
+	return d.Category != d.SuggestedCategory
+}
+
+// NewReview categorizes every parsed transaction and proposes a decision for
+// it: likely duplicates are rejected, everything else is accepted
+func NewReview(result *ImportResult, c *categorizer.Categorizer) []RowDecision {
+	decisions, _ := newReview(context.Background(), result, c, nil)
+	return decisions
+}
+
+// newReview is NewReview for the import pipelines: it stops when ctx is
+// canceled and reports how many rows are done every progressInterval rows
+func newReview(ctx context.Context, result *ImportResult, c *categorizer.Categorizer, onProgress func(done int)) ([]RowDecision, error) {
+	decisions := make([]RowDecision, len(result.Transactions))
+	for i := range result.Transactions {
+		if i%100 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if onProgress != nil && i > 0 && i%progressInterval == 0 {
+			onProgress(i)
+		}
+
+		decisions[i] = newDecision(result, i, c)
+	}
+	return decisions, nil
+}
+
+// newDecision categorizes the transaction at index i of result
+func newDecision(result *ImportResult, i int, c *categorizer.Categorizer) RowDecision {
+	t := result.Transactions[i]
+	suggestion := c.Categorize(t)
+	if t.Category != "" && t.Category != "Uncategorized" {
+		// The file already says which category the row belongs to
+		suggestion = categorizer.Categorization{Category: t.Category, Confidence: 1}
+	}
+	_, duplicate := result.DuplicateOf(i)
+
+	action := RowAccept
+	if duplicate {
+		action = RowReject
+	}
+
+	decision := RowDecision{
+		Row:               i,
+		Action:            action,
+		Description:       t.Description,
+		SuggestedCategory: suggestion.Category,
+		Category:          suggestion.Category,
+		Confidence:        suggestion.Confidence,
+		Duplicate:         duplicate,
+		IsTransfer:        suggestion.Actions.Transfer,
+		Rule:              suggestion.Rule,
+		MatchedBy:         suggestion.MatchedBy,
+	}
+	if suggestion.Actions.String() != "" {
+		decision.Actions = &suggestion.Actions
+	}
+	return decision
+}
+
+// FixRow parses a failed row again with its fixed fields. When it parses,
+// the transaction is checked for duplicates against existing and the other
+// files of the import, categorized and added to the review. It returns the
+// index of the row's decision.
+func (s *ImportSession) FixRow(result *ImportResult, i int, record []string, existing []budget.Transaction, c *categorizer.Categorizer) (int, error) {
+	file := result.Errors[i].File
+	row, err := result.ReparseError(i, record)
+	if err != nil {
+		s.Errors = result.ErrorMessages()
+		return -1, err
+	}
+
+	// Match as the first pass did: against existing transactions and the
+	// other files' rows, less those another row has already matched
+	index := NewDuplicateIndex(existing)
+	for j, t := range result.Transactions[:row] {
+		if from := s.FileOf(j); from != nil && from.Path != file {
+			index.Add(t)
+		}
+	}
+	for _, match := range result.Duplicates {
+		index.consume(match.ExistingID)
+	}
+	if match, ok := index.Match(result.Transactions[row]); ok {
+		match.Index = row
+		result.Duplicates = append(result.Duplicates, match)
+	}
+
+	for j := range s.Files {
+		if s.Files[j].Path != file {
+			continue
+		}
+		summary := &s.Files[j]
+		summary.SuccessCount++
+		summary.Fixed = append(summary.Fixed, row)
+		summary.Errors = nil
+		for _, e := range result.Errors {
+			if e.File == file {
+				summary.Errors = append(summary.Errors, e.Error())
+			}
+		}
+		if _, duplicate := result.DuplicateOf(row); duplicate {
+			summary.Duplicates++
+		}
+	}
+
+	s.TotalCount = len(result.Transactions)
+	s.Errors = result.ErrorMessages()
+	s.SetDateRange(result.Transactions)
+	s.Decisions = append(s.Decisions, newDecision(result, row, c))
+	return len(s.Decisions) - 1, nil
+}
+
+// AcceptAbove accepts every row the categorizer is at least threshold
+// confident about, leaving likely duplicates alone
+func AcceptAbove(decisions []RowDecision, threshold float64) int {
+	count := 0
+	for i := range decisions {
+		if !decisions[i].Duplicate && decisions[i].Confidence >= threshold && decisions[i].Action != RowAccept {
+			decisions[i].Action = RowAccept
+			count++
+		}
+	}
+	return count
+}
+
+// RejectBelow rejects every row the categorizer is less than threshold
+// confident about
+func RejectBelow(decisions []RowDecision, threshold float64) int {
+	count := 0
+	for i := range decisions {
+		if decisions[i].Confidence < threshold && decisions[i].Action != RowReject {
+			decisions[i].Action = RowReject
+			count++
+		}
+	}
+	return count
+}
+
+// AcceptedTransactions returns the accepted rows of result with the review
+// edits applied
+func AcceptedTransactions(result *ImportResult, decisions []RowDecision) []budget.Transaction {
+	var accepted []budget.Transaction
+	for _, d := range decisions {
+		if d.Action != RowAccept || d.Row < 0 || d.Row >= len(result.Transactions) {
+			continue
+		}
+
+		t := result.Transactions[d.Row]
+		t.Description = d.Description
+		t.Category = d.Category
+		t.Confidence = d.Confidence
+		t.ManualCategory = d.Edited()
+		// Kept even when the category was changed, which counts against
+		// the rule
+		t.CategorizedBy, t.MatchedBy = d.Rule, d.MatchedBy
+		if d.Actions != nil && !d.Edited() {
+			d.Actions.Apply(&t)
+		}
+		// The decision starts out with the rule's transfer action, so what
+		// the user made of it wins
+		t.IsTransfer = d.IsTransfer
+		accepted = append(accepted, t)
+	}
+	return accepted
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+func TestFixRowDuplicates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := categorizer.NewCategorizer()
+	format := CommonFormats[len(CommonFormats)-1]
+	coffee := budget.Transaction{ID: "old", Amount: 4.5, Description: "Coffee", Type: budget.Expense, Date: day(5)}
+	secondCoffee := coffee
+	secondCoffee.ID = "old2"
+	fixed := []string{"2024-01-05", "Coffee", "-4.50"}
+
+	tests := []struct {
+		name     string
+		files    []string
+		existing []budget.Transaction
+		want     bool
+	}{
+		{"identical row in the same file", []string{"2024-01-05,Coffee,-4.50\n2024-01-05,Coffee,oops\n"}, nil, false},
+		{"existing already matched", []string{"2024-01-05,Coffee,-4.50\n2024-01-05,Coffee,oops\n"}, []budget.Transaction{coffee}, false},
+		{"second existing left", []string{"2024-01-05,Coffee,-4.50\n2024-01-05,Coffee,oops\n"}, []budget.Transaction{coffee, secondCoffee}, true},
+		{"row in another file", []string{"2024-01-05,Coffee,oops\n", "2024-01-05,Coffee,-4.50\n"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var paths []string
+			for i, content := range tt.files {
+				path := filepath.Join(dir, string(rune('a'+i))+".csv")
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+			}
+
+			var session *ImportSession
+			var result *ImportResult
+			var err error
+			if len(paths) == 1 {
+				session, result, err = PrepareImport(context.Background(), paths[0], &format, "", nil, "", tt.existing, c, nil)
+			} else {
+				session, result, err = PrepareBatchImport(context.Background(), paths, &format, "", nil, "", tt.existing, c, nil)
+			}
+			if err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+			if len(result.Errors) != 1 {
+				t.Fatalf("got %d row errors, want 1: %s", len(result.Errors), strings.Join(result.ErrorMessages(), "; "))
+			}
+
+			decision, err := session.FixRow(result, 0, fixed, tt.existing, c)
+			if err != nil {
+				t.Fatalf("FixRow() error = %v", err)
+			}
+			if got := session.Decisions[decision].Duplicate; got != tt.want {
+				t.Errorf("fixed row duplicate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptAboveRejectBelow(t *testing.T) {
+	decisions := func() []RowDecision {
+		return []RowDecision{
+			{Row: 0, Action: RowReject, Confidence: 0.95},
+			{Row: 1, Action: RowReject, Confidence: 0.95, Duplicate: true},
+			{Row: 2, Action: RowAccept, Confidence: 0.4},
+			{Row: 3, Action: RowReject, Confidence: 0.6},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		apply     func([]RowDecision) int
+		wantCount int
+		want      []RowAction
+	}{
+		{"accept above", func(d []RowDecision) int { return AcceptAbove(d, 0.8) }, 1, []RowAction{RowAccept, RowReject, RowAccept, RowReject}},
+		{"accept above keeps duplicates", func(d []RowDecision) int { return AcceptAbove(d, 0) }, 2, []RowAction{RowAccept, RowReject, RowAccept, RowAccept}},
+		{"reject below", func(d []RowDecision) int { return RejectBelow(d, 0.5) }, 1, []RowAction{RowReject, RowReject, RowReject, RowReject}},
+		{"reject below nothing", func(d []RowDecision) int { return RejectBelow(d, 0.1) }, 0, []RowAction{RowReject, RowReject, RowAccept, RowReject}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decisions()
+			if count := tt.apply(d); count != tt.wantCount {
+				t.Errorf("changed %d rows, want %d", count, tt.wantCount)
+			}
+			for i, want := range tt.want {
+				if d[i].Action != want {
+					t.Errorf("row %d = %s, want %s", i, d[i].Action, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAcceptedTransactions(t *testing.T) {
+	result := &ImportResult{Transactions: []budget.Transaction{
+		{Description: "COSTCO #1", Amount: 100, Type: budget.Expense},
+	}}
+	costco := &categorizer.RuleActions{Payee: "Costco", Tags: []string{"bulk"}, Transfer: true}
+
+	tests := []struct {
+		name         string
+		decision     RowDecision
+		wantCount    int
+		wantPayee    string
+		wantManual   bool
+		wantTransfer bool
+	}{
+		{"rejected", RowDecision{Row: 0, Action: RowReject, Category: "Shopping", SuggestedCategory: "Shopping"}, 0, "", false, false},
+		{"row out of range", RowDecision{Row: 5, Action: RowAccept}, 0, "", false, false},
+		{"as suggested", RowDecision{Row: 0, Action: RowAccept, Category: "Shopping", SuggestedCategory: "Shopping", Actions: costco, IsTransfer: true}, 1, "Costco", false, true},
+		{"category changed", RowDecision{Row: 0, Action: RowAccept, Category: "Groceries", SuggestedCategory: "Shopping", Actions: costco, IsTransfer: true}, 1, "", true, true},
+		{"transfer unmarked", RowDecision{Row: 0, Action: RowAccept, Category: "Shopping", SuggestedCategory: "Shopping", Actions: costco}, 1, "Costco", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted := AcceptedTransactions(result, []RowDecision{tt.decision})
+			if len(accepted) != tt.wantCount {
+				t.Fatalf("accepted %d rows, want %d", len(accepted), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			got := accepted[0]
+			if got.Category != tt.decision.Category {
+				t.Errorf("category = %q, want %q", got.Category, tt.decision.Category)
+			}
+			if got.Payee != tt.wantPayee {
+				t.Errorf("payee = %q, want %q", got.Payee, tt.wantPayee)
+			}
+			if got.ManualCategory != tt.wantManual {
+				t.Errorf("manual category = %v, want %v", got.ManualCategory, tt.wantManual)
+			}
+			if got.IsTransfer != tt.wantTransfer {
+				t.Errorf("transfer = %v, want %v", got.IsTransfer, tt.wantTransfer)
+			}
+		})
+	}
+	if result.Transactions[0].Payee != "" {
+		t.Error("AcceptedTransactions changed the parsed rows")
+	}
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// RowErrorKind says what was wrong with a row that couldn't be imported
+type RowErrorKind string
+
+const (
+	ErrMalformedLine      RowErrorKind = "malformed_line"
+	ErrMissingColumns     RowErrorKind = "missing_columns"
+	ErrInvalidDate        RowErrorKind = "invalid_date"
+	ErrMissingDescription RowErrorKind = "missing_description"
+	ErrInvalidAmount      RowErrorKind = "invalid_amount"
+	ErrMissingAmount      RowErrorKind = "missing_amount"
+	ErrInvalidType        RowErrorKind = "invalid_type"
+	ErrInvalidBalance     RowErrorKind = "invalid_balance"
+	ErrUnreadableFile     RowErrorKind = "unreadable_file"
+)
+
+// RowError is a row of a statement that couldn't be imported
+type RowError struct {
+	File    string       `json:"file,omitempty"`
+	Row     int          `json:"row"`    // record number in the file, counting the header
+	Column  int          `json:"column"` // NoColumn when the whole row is at fault
+	Value   string       `json:"value,omitempty"`
+	Kind    RowErrorKind `json:"kind"`
+	Message string       `json:"message"`
+	Fix     string       `json:"fix,omitempty"`
+	// Record holds the row's raw fields so it can be fixed and parsed again
+	Record []string `json:"record,omitempty"`
+}
+
+func (e *RowError) Error() string {
+	if e.Row == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("Row %d: %s", e.Row, e.Message)
+}
+
+// UnmarshalJSON also accepts the plain "Row 5: invalid date" strings that
+// older versions stored, so queued imports still load
+func (e *RowError) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*e = RowError{Column: NoColumn, Message: message}
+		return nil
+	}
+
+	type plain RowError
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// newRowError describes a problem with one field of a row
+func newRowError(kind RowErrorKind, column int, value, message, fix string) *RowError {
+	return &RowError{Column: column, Value: value, Kind: kind, Message: message, Fix: fix}
+}
+
+// asRowError returns err as a *RowError, describing any other error as a
+// row that couldn't be read
+func asRowError(err error) *RowError {
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		return rowErr
+	}
+	return newRowError(ErrMalformedLine, NoColumn, "", err.Error(), "")
+}
+
+// ColumnName names the field of format that column holds
+func (f *CSVFormat) ColumnName(column int) string {
+	switch column {
+	case NoColumn:
+		return ""
+	case f.DateColumn:
+		return "date"
+	case f.DescriptionColumn:
+		return "description"
+	case f.AmountColumn:
+		return "amount"
+	case f.DebitColumn:
+		return "debit"
+	case f.CreditColumn:
+		return "credit"
+	case f.TypeColumn:
+		return "type"
+	case f.BalanceColumn:
+		return "balance"
+	case f.IDColumn:
+		return "transaction ID"
+	default:
+		return fmt.Sprintf("column %d", column+1)
+	}
+}
+
+// dateExample shows what a date in format looks like
+func (f *CSVFormat) dateExample() string {
+	return time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC).Format(f.DateFormat)
+}
+
+// amountExample shows what an amount in the format's locale looks like
+func (f *CSVFormat) amountExample() string {
+	locale := f.locale()
+	group := ""
+	if len(locale.GroupSeparators) > 0 {
+		group = string(locale.GroupSeparators[0])
+	}
+	return fmt.Sprintf("1%s234%c56", group, locale.DecimalSeparator)
+}
+
+// ReparseError parses the fixed fields of a failed row. On success the
+// transaction is added to the result and the error removed; otherwise the
+// error is replaced with the new one. It returns the index of the added
+// transaction.
+func (r *ImportResult) ReparseError(i int, record []string) (int, error) {
+	if i < 0 || i >= len(r.Errors) {
+		return -1, fmt.Errorf("no error %d", i)
+	}
+	old := r.Errors[i]
+	format := r.FormatOf(old)
+
+	var transaction budget.Transaction
+	var err error
+	if format.JSON {
+		var rowErr *RowError
+		if transaction, rowErr = parseJSONTransaction(strings.Join(record, "")); rowErr != nil {
+			err = rowErr
+		}
+	} else {
+		transaction, err = parseRow(format, record)
+	}
+	if err != nil {
+		rowErr := asRowError(err)
+		rowErr.File = old.File
+		rowErr.Row = old.Row
+		rowErr.Record = record
+		r.Errors[i] = *rowErr
+		return -1, rowErr
+	}
+
+	if transaction.Account == "" {
+		transaction.Account = r.Account
+	}
+	r.Errors = append(r.Errors[:i], r.Errors[i+1:]...)
+	r.Transactions = append(r.Transactions, transaction)
+	r.SuccessCount++
+	return len(r.Transactions) - 1, nil
+}
+
+// FormatOf returns the format the error's row was parsed with, which in a
+// batch import depends on the file it came from
+func (r *ImportResult) FormatOf(e RowError) *CSVFormat {
+	if format, ok := r.FileFormats[e.File]; ok {
+		return &format
+	}
+	return &r.Format
+}
+
+// SplitRecord turns an edited line back into fields using the format's
+// delimiter, for rows whose whole line is being fixed
+func (f *CSVFormat) SplitRecord(line string) ([]string, error) {
+	if f.JSON {
+		return []string{line}, nil
+	}
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = f.Delimiter
+	reader.FieldsPerRecord = -1
+	return reader.Read()
+}
+
+// JoinRecord is the inverse of SplitRecord
+func (f *CSVFormat) JoinRecord(record []string) string {
+	if f.JSON {
+		return strings.Join(record, "")
+	}
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Comma = f.Delimiter
+	writer.Write(record)
+	writer.Flush()
+	return strings.TrimRight(sb.String(), "\r\n")
+}
+
+// ErrorMessages returns the result's errors as text, as kept in the import
+// history
+func (r *ImportResult) ErrorMessages() []string {
+	messages := make([]string, len(r.Errors))
+	for i := range r.Errors {
+		messages[i] = r.Errors[i].Error()
+		if r.Errors[i].File != "" {
+			messages[i] = filepath.Base(r.Errors[i].File) + ": " + messages[i]
+		}
+	}
+	return messages
+}
+
+// RejectedRowsPath is where ExportRejectedRows writes the rows of a session
+// that couldn't be imported: next to the statement, or next to the first
+// file of a batch
+func RejectedRowsPath(session *ImportSession) string {
+	paths := session.Paths()
+	if len(paths) == 1 {
+		return strings.TrimSuffix(paths[0], filepath.Ext(paths[0])) + "-rejected.csv"
+	}
+	return filepath.Join(filepath.Dir(paths[0]), "import-"+session.ID+"-rejected.csv")
+}
+
+// ExportRejectedRows writes every row that couldn't be imported to a CSV
+// file, with the problem and suggested fix before the row's original fields
+func (r *ImportResult) ExportRejectedRows(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	fields := 0
+	for _, e := range r.Errors {
+		fields = max(fields, len(e.Record))
+	}
+
+	header := []string{"file", "row", "kind", "column", "error", "suggested fix"}
+	for i := 0; i < fields; i++ {
+		if i < len(r.Header) {
+			header = append(header, r.Header[i])
+		} else {
+			header = append(header, fmt.Sprintf("field %d", i+1))
+		}
+	}
+
+	writer := csv.NewWriter(file)
+	writer.Write(header)
+	for _, e := range r.Errors {
+		name := ""
+		if e.File != "" {
+			name = filepath.Base(e.File)
+		}
+		row := []string{
+			name,
+			strconv.Itoa(e.Row),
+			string(e.Kind),
+			r.FormatOf(e).ColumnName(e.Column),
+			e.Message,
+			e.Fix,
+		}
+		writer.Write(append(row, e.Record...))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
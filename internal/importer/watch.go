@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// PendingImport is a watched file that has been parsed and categorized and
+// is waiting for review
+type PendingImport struct {
+	Session ImportSession `json:"session"`
+	Result  ImportResult  `json:"result"`
+}
+
+// PendingQueue holds imports found by the watch folder, plus the hash of
+// every file it has already looked at so nothing is picked up twice
+type PendingQueue struct {
+	Items      []PendingImport `json:"items"`
+	SeenHashes []string        `json:"seen_hashes"`
+}
+
+// WatchedFile is a new file found in the watch folder
+type WatchedFile struct {
+	Path string
+	Hash string
+}
+
+func getPendingQueuePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".budget_tui_pending.json")
+}
+
+func LoadPendingQueue() (*PendingQueue, error) {
+	filePath := getPendingQueuePath()
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return &PendingQueue{Items: []PendingImport{}}, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &PendingQueue{Items: []PendingImport{}}, nil
+	}
+
+	var queue PendingQueue
+	err = json.Unmarshal(data, &queue)
+	if err != nil {
+		return &PendingQueue{Items: []PendingImport{}}, nil
+	}
+
+	return &queue, nil
+}
+
+func (q *PendingQueue) Save() error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getPendingQueuePath(), data, 0644)
+}
+
+func (q *PendingQueue) Add(session ImportSession, result ImportResult) {
+	q.Items = append(q.Items, PendingImport{Session: session, Result: result})
+}
+
+// Remove drops the pending import for a session, reporting whether it was
+// in the queue
+func (q *PendingQueue) Remove(sessionID string) bool {
+	for i, item := range q.Items {
+		if item.Session.ID == sessionID {
+			q.Items = append(q.Items[:i], q.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *PendingQueue) MarkSeen(hash string) {
+	if hash != "" && !q.HasSeen(hash) {
+		q.SeenHashes = append(q.SeenHashes, hash)
+	}
+}
+
+func (q *PendingQueue) HasSeen(hash string) bool {
+	for _, seen := range q.SeenHashes {
+		if seen == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Transactions returns every parsed row still waiting in the queue, so new
+// files can be checked for duplicates against them too
+func (q *PendingQueue) Transactions() []budget.Transaction {
+	var transactions []budget.Transaction
+	for _, item := range q.Items {
+		transactions = append(transactions, item.Result.Transactions...)
+	}
+	return transactions
+}
+
+// settleTime is how long a file must go unmodified before it is picked up,
+// so statements that are still downloading are left alone
+const settleTime = 5 * time.Second
+
+// ScanWatchFolder lists supported files in dir whose contents haven't been
+// seen before, oldest first. A file is identified by its hash, so renaming or
+// re-downloading a statement doesn't import it again.
+func ScanWatchFolder(dir string, seen func(hash string) bool) ([]WatchedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		infoI, errI := entries[i].Info()
+		infoJ, errJ := entries[j].Info()
+		if errI != nil || errJ != nil {
+			return entries[i].Name() < entries[j].Name()
+		}
+		return infoI.ModTime().Before(infoJ.ModTime())
+	})
+
+	var files []WatchedFile
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !isSupportedFile(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < settleTime {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		hash, err := HashFile(path)
+		if err != nil || seen(hash) {
+			continue
+		}
+		files = append(files, WatchedFile{Path: path, Hash: hash})
+	}
+	return files, nil
+}
+
+func isSupportedFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, supported := range SupportedExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
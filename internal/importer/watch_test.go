@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestScanWatchFolder(t *testing.T) {
+	dir := t.TempDir()
+	settled := time.Now().Add(-time.Hour)
+	files := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{"march.csv", settled.Add(2 * time.Minute)},
+		{"january.csv", settled},
+		{"february.CSV", settled.Add(time.Minute)},
+		{"seen.csv", settled},
+		{"downloading.csv", time.Now()},
+		{"notes.pdf", settled},
+		{".hidden.csv", settled},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, []byte("2024-01-01,"+file.name+",-4.50\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, file.modTime, file.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "old.csv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	seenHash, err := HashFile(filepath.Join(dir, "seen.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := &PendingQueue{}
+	queue.MarkSeen(seenHash)
+	found, err := ScanWatchFolder(dir, queue.HasSeen)
+	if err != nil {
+		t.Fatalf("ScanWatchFolder() error = %v", err)
+	}
+	var names []string
+	for _, file := range found {
+		names = append(names, filepath.Base(file.Path))
+		if file.Hash == "" {
+			t.Errorf("%s has no hash", file.Path)
+		}
+	}
+	// Oldest first, without the recent, hidden, unsupported or seen files
+	want := []string{"january.csv", "february.CSV", "march.csv"}
+	if !slices.Equal(names, want) {
+		t.Errorf("ScanWatchFolder() = %q, want %q", names, want)
+	}
+
+	if _, err := ScanWatchFolder(filepath.Join(dir, "missing"), queue.HasSeen); err == nil {
+		t.Error("ScanWatchFolder() on a missing folder returned no error")
+	}
+}
+
+func TestPendingQueue(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	coffee := budget.Transaction{Description: "Coffee", Amount: 4.5}
+	rent := budget.Transaction{Description: "Rent", Amount: 900}
+
+	queue, err := LoadPendingQueue()
+	if err != nil {
+		t.Fatalf("LoadPendingQueue() error = %v", err)
+	}
+	queue.Add(ImportSession{ID: "a"}, ImportResult{Transactions: []budget.Transaction{coffee}})
+	queue.Add(ImportSession{ID: "b"}, ImportResult{Transactions: []budget.Transaction{rent}})
+	queue.MarkSeen("hash-a")
+	queue.MarkSeen("hash-a")
+	queue.MarkSeen("")
+	if err := queue.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadPendingQueue()
+	if err != nil {
+		t.Fatalf("LoadPendingQueue() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"items", len(loaded.Items), 2},
+		{"seen hashes", len(loaded.SeenHashes), 1},
+		{"seen", loaded.HasSeen("hash-a"), true},
+		{"not seen", loaded.HasSeen("hash-b"), false},
+		{"waiting rows", len(loaded.Transactions()), 2},
+		{"remove queued", loaded.Remove("a"), true},
+		{"remove again", loaded.Remove("a"), false},
+		{"left", loaded.Items[0].Session.ID, "b"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
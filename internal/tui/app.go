@@ -0,0 +1,351 @@
+package tui
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/config"
+	"github.com/Elwdipath/budget_tui/internal/importer"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+type state int
+
+const (
+	dashboardState state = iota
+	importState
+	reviewState
+	addIncomeState
+	addExpenseState
+	viewTransactionsState
+	importHistoryState
+	reconcileState
+	ruleSuggestionsState
+	recategorizeState
+	rulesState
+	explainState
+)
+
+type model struct {
+	state       state
+	budget      *budget.Budget
+	menuChoices []string
+	menuCursor  int
+
+	// Form fields
+	amountInput      string
+	descriptionInput string
+	categoryInput    string
+	activeField      int
+	formSubmitted    bool
+
+	// Dashboard state
+	dashboardCursor     int
+	selectedCategory    int
+	selectedTransaction int
+	showHelp            bool
+
+	// Transactions view state
+	transactionEditing bool
+	transactionInput   string
+	transactionStatus  string
+
+	// Import state
+	config           *config.Config
+	fileBrowser      *FileBrowser
+	pathInputFocused bool
+	importFilePath   string
+	importEncoding   importer.Encoding
+	// importLocale names the locale that replaces the format's own, or is
+	// empty to keep it
+	importLocale string
+	// importAccount is given to imported rows the file has no account for
+	importAccount       string
+	accountInputFocused bool
+	importFormat        *importer.CSVFormat
+	importResult        *importer.ImportResult
+	importSession       *importer.ImportSession
+	importHistory       *importer.ImportHistory
+	pendingQueue        *importer.PendingQueue
+	watchStatus         string
+	categorizer         *categorizer.Categorizer
+	selectedPreview     int
+	showImportDetails   bool
+	reviewEditField     string
+	reviewInput         string
+	showImportErrors    bool
+	selectedError       int
+	errorEditColumn     int // the field being fixed, or NoColumn for the whole line
+	importStatus        string
+	importUpdates       chan tea.Msg // messages from the import running in the background
+	importCancel        context.CancelFunc
+	importProgress      importer.ImportProgress
+	importErr           *importer.ImportError
+	spinnerFrame        int
+	retryPaths          []string
+	retryFormat         *importer.CSVFormat
+	startupCmd          tea.Cmd
+
+	// Import history state
+	historyCursor    int
+	historySearching bool
+	historyQuery     string
+	historyDetailID  string
+	rerunFormat      int
+	rerunFileHash    string
+	confirmUndo      bool
+
+	// Reconcile state
+	reconcileAccount int
+	reconcile        budget.Reconciliation
+	reconcileEditing bool
+	reconcileField   int
+	reconcileInputs  [3]string // statement date, opening balance, ending balance
+	reconcileCursor  int
+	reconcileStatus  string
+
+	// Rule suggestions state
+	suggestions         []categorizer.RuleSuggestion
+	suggestionCursor    int
+	suggestionEditing   bool
+	suggestionInput     string
+	pendingRecategorize []int // transactions waiting for y/n to take an accepted rule's category
+	pendingCategory     string
+	pendingManual       int // matching transactions left alone because their category was chosen by hand
+	suggestionStatus    string
+
+	// Recategorize state
+	recatInputs   [4]string // from, to, source, current category
+	recatField    int       // recatProtectField is the protect toggle
+	recatProtect  bool
+	recatChanges  []categorizer.CategoryChange
+	recatExcluded map[int]bool
+	recatCursor   int
+	recatPlanned  bool
+	recatStatus   string
+
+	// Rules state
+	ruleCursor        int
+	ruleEditing       bool
+	ruleEditIndex     int // -1 for a new rule
+	ruleInputs        [ruleFieldCount]string
+	ruleField         int
+	ruleConfirmDelete bool
+	ruleStatus        string
+	ruleLint          []categorizer.LintFinding
+	// ruleLintRun numbers the lint runs, so only the latest one's findings
+	// are kept, and ruleLinting is set while it runs
+	ruleLintRun int
+	ruleLinting bool
+
+	// Explain console state
+	explainInputs [2]string // description, amount
+	explainType   budget.TransactionType
+	explainField  int // 2 is the type toggle
+	explainOffset int // first rule shown
+	explainReturn state
+	// explainBase is the transaction being explained, for the account,
+	// date and source rules can test
+	explainBase budget.Transaction
+}
+
+const (
+	// reviewPageSize is how many rows the review screen shows at once
+	reviewPageSize = 10
+	// recatProtectField is the recategorize filter's last field, the toggle
+	// that protects manually set categories
+	recatProtectField = 4
+	// ruleFieldCount is the number of fields in the rule editor
+	ruleFieldCount = 7
+	// bulkConfidence is the threshold for the review screen's bulk actions
+	bulkConfidence = 0.8
+	// fileBrowserHeight is how many entries the import file browser shows
+	fileBrowserHeight = 12
+)
+
+func initialModel() model {
+	b, _ := budget.LoadBudget()
+	importHistory, _ := importer.LoadImportHistory()
+	pendingQueue, _ := importer.LoadPendingQueue()
+	cfg, _ := config.LoadConfig()
+	c := categorizer.NewCategorizer()
+	c.Train(b.Transactions)
+	return model{
+		state:  dashboardState,
+		budget: b,
+		menuChoices: []string{
+			"[i] Income  [e] Expense  [t] Transactions  [b] Import  [H] History  [r] Reconcile  [R] Rules  [S] Suggest rules  [C] Recategorize  [X] Explain  [h] Help  [q] Quit",
+		},
+		menuCursor:          0,
+		activeField:         0,
+		dashboardCursor:     0,
+		selectedCategory:    0,
+		selectedTransaction: 0,
+		showHelp:            false,
+		importHistory:       importHistory,
+		pendingQueue:        pendingQueue,
+		config:              cfg,
+		fileBrowser:         NewFileBrowser(cfg.GetDownloadsDir(), importer.SupportedExtensions),
+		importEncoding:      importer.EncodingAuto,
+		importLocale:        configLocale(cfg),
+		categorizer:         c,
+		selectedPreview:     0,
+		showImportDetails:   false,
+		importStatus:        "ready",
+	}
+}
+
+// NewModel loads the budget and opens the dashboard. Files in importPaths
+// are imported straight away.
+func NewModel(importPaths []string) tea.Model {
+	m := initialModel()
+	if len(importPaths) > 0 {
+		m.state = importState
+		m.startupCmd = m.startBatchImport(importPaths, nil)
+	}
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.scanWatchFolder(), m.startupCmd)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// While an import runs in the background, Esc cancels it
+		if m.importUpdates != nil {
+			switch msg.String() {
+			case "esc":
+				m.importCancel()
+				m.importStatus = "canceling import..."
+			case "ctrl+c":
+				m.importCancel()
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		switch m.state {
+		case dashboardState:
+			return m.updateDashboard(msg)
+		case importState:
+			return m.updateImportState(msg)
+		case reviewState:
+			return m.updateReviewState(msg)
+		case addIncomeState, addExpenseState:
+			return m.updateAddTransactionForm(msg)
+		case viewTransactionsState:
+			return m.updateViewTransactions(msg)
+		case importHistoryState:
+			return m.updateImportHistory(msg)
+		case reconcileState:
+			return m.updateReconcile(msg)
+		case ruleSuggestionsState:
+			return m.updateRuleSuggestions(msg)
+		case recategorizeState:
+			return m.updateRecategorize(msg)
+		case rulesState:
+			return m.updateRules(msg)
+		case explainState:
+			return m.updateExplain(msg)
+		}
+	case importProgressMsg:
+		// Keep draining an abandoned import so its goroutine can finish
+		if msg.updates != m.importUpdates {
+			return m, waitForImport(msg.updates)
+		}
+		m.importProgress = msg.progress
+		return m, waitForImport(msg.updates)
+	case importDoneMsg:
+		if msg.updates == m.importUpdates {
+			m.finishImport(msg.session, msg.result, msg.err)
+		}
+		return m, nil
+	case spinnerTickMsg:
+		if m.importUpdates == nil {
+			return m, nil
+		}
+		m.spinnerFrame++
+		return m, spinnerTick()
+	case watchTickMsg:
+		return m, m.scanWatchFolder()
+	case watchScanMsg:
+		m.applyWatchScan(msg)
+		return m, m.watchTick()
+	case ruleLintMsg:
+		if msg.run == m.ruleLintRun {
+			m.ruleLint = msg.findings
+			m.ruleLinting = false
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	switch m.state {
+	case dashboardState:
+		return m.viewDashboard()
+	case importState:
+		return m.viewImportState()
+	case reviewState:
+		return m.viewReviewState()
+	case addIncomeState, addExpenseState:
+		return m.viewAddTransactionForm()
+	case viewTransactionsState:
+		return m.viewTransactions()
+	case importHistoryState:
+		return m.viewImportHistory()
+	case reconcileState:
+		return m.viewReconcile()
+	case ruleSuggestionsState:
+		return m.viewRuleSuggestions()
+	case recategorizeState:
+		return m.viewRecategorize()
+	case rulesState:
+		return m.viewRules()
+	case explainState:
+		return m.viewExplain()
+	default:
+		return ""
+	}
+}
+
+// completeCategory returns the first known category starting with prefix
+func completeCategory(categories []string, prefix string) string {
+	sort.Strings(categories)
+	for _, category := range categories {
+		if strings.HasPrefix(strings.ToLower(category), strings.ToLower(prefix)) {
+			return category
+		}
+	}
+	return prefix
+}
+
+// editInput applies a key press to a single-line text input
+func editInput(value string, msg tea.KeyMsg) string {
+	switch msg.Type {
+	case tea.KeyBackspace:
+		runes := []rune(value)
+		if len(runes) > 0 {
+			return string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		return value + " "
+	case tea.KeyRunes:
+		return value + string(msg.Runes)
+	}
+	return value
+}
+
+func toggleType(t budget.TransactionType) budget.TransactionType {
+	if t == budget.Expense {
+		return budget.Income
+	}
+	return budget.Expense
+}
@@ -87,3 +87,14 @@ func GetGrayColor() lipgloss.Color              { return grayColor }
 func FormatAmount(amount float64) string {
 	return fmt.Sprintf("%.2f", amount)
 }
+
+// Truncate shortens s to at most width characters, ending it with tail when
+// it is cut. It counts runes, so multibyte text isn't cut mid-character.
+func Truncate(s string, width int, tail string) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	keep := max(width-len([]rune(tail)), 0)
+	return string(runes[:keep]) + tail
+}
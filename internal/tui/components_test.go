@@ -0,0 +1,28 @@
+package tui
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		tail  string
+		want  string
+	}{
+		{"fits", "statement.csv", 32, "...", "statement.csv"},
+		{"exact", "abcdef", 6, "...", "abcdef"},
+		{"ascii", "abcdefghij", 8, "...", "abcde..."},
+		{"multibyte", "relevé-café-crédit-agricole-été.csv", 12, "...", "relevé-ca..."},
+		{"single rune tail", "Épicerie du coin", 9, "…", "Épicerie…"},
+		{"wider tail", "abcdef", 2, "...", "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.input, tt.width, tt.tail); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
 	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/Elwdipath/budget_tui/internal/budget"
 )
@@ -121,3 +124,161 @@ func renderBalance(balance float64) string {
 		return negativeStyle.Render(amountStr)
 	}
 }
+
+func (m model) updateDashboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "i":
+		m.state = addIncomeState
+		m.resetForm()
+	case "e":
+		m.state = addExpenseState
+		m.resetForm()
+	case "t":
+		m.state = viewTransactionsState
+		m.selectedTransaction = 0
+	case "b":
+		m.state = importState
+		m.resetImportState()
+	case "p":
+		m.openPendingImport()
+	case "H":
+		m.state = importHistoryState
+		m.historyCursor = 0
+		m.historyQuery = ""
+		m.historyDetailID = ""
+		m.confirmUndo = false
+	case "r":
+		m.state = reconcileState
+		m.reconcileAccount = 0
+		m.startReconcile()
+	case "S":
+		m.state = ruleSuggestionsState
+		m.openRuleSuggestions()
+	case "R":
+		m.state = rulesState
+		m.ruleCursor = 0
+		m.ruleEditing = false
+		m.ruleConfirmDelete = false
+		m.ruleStatus = ""
+		return m, m.lintRules()
+	case "X":
+		m.openExplain(budget.Transaction{Type: budget.Expense, Date: time.Now()})
+	case "C":
+		m.state = recategorizeState
+		m.recatInputs = [4]string{}
+		m.recatField = 0
+		m.recatProtect = true
+		m.recatPlanned = false
+		m.recatStatus = "set filters, then Enter for a dry run"
+	case "h":
+		m.showHelp = !m.showHelp
+	case "up", "k":
+		if m.dashboardCursor > 0 {
+			m.dashboardCursor--
+		}
+	case "down", "j":
+		categories := m.budget.GetSpendingByCategory()
+		if m.dashboardCursor < len(categories)-1 {
+			m.dashboardCursor++
+		}
+	case "left", "right":
+		// Switch between dashboard panels
+		if msg.String() == "left" && m.selectedTransaction > 0 {
+			m.selectedTransaction--
+		} else if msg.String() == "right" {
+			m.selectedTransaction++
+		}
+	case "esc":
+		// Return to dashboard from other views will be handled by their own update functions
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m model) viewDashboard() string {
+	// Hero Banner
+	heroBanner := GetHeroBanner()
+	subtitle := GetSubtitle()
+
+	// Financial Summary Panel
+	summaryContent := RenderFinancialSummary(m.budget)
+	summaryPanel := summaryPanelStyle.Render(summaryContent)
+
+	// Category Spending Panel
+	categories := m.budget.GetSpendingByCategory()
+	var categoryContent string
+
+	if len(categories) == 0 {
+		categoryContent = "No expense categories yet.\n\nAdd some expenses to see breakdown."
+	} else {
+		var sb strings.Builder
+		sb.WriteString("Top Spending Categories:\n\n")
+
+		totalExpenses := m.budget.GetTotalExpenses()
+		maxDisplay := 5
+		if len(categories) < maxDisplay {
+			maxDisplay = len(categories)
+		}
+
+		for i := 0; i < maxDisplay; i++ {
+			cat := categories[i]
+			bar := RenderCategoryBar(cat.Category, cat.Amount, totalExpenses, 15)
+
+			if i == m.dashboardCursor {
+				bar = positiveStyle.Render("►") + bar[1:]
+			}
+
+			sb.WriteString(bar + "\n")
+			sb.WriteString(fmt.Sprintf("           $%s (%d items)\n\n", FormatAmount(cat.Amount), cat.Count))
+		}
+
+		if len(categories) > maxDisplay {
+			sb.WriteString(fmt.Sprintf("... and %d more categories\n", len(categories)-maxDisplay))
+		}
+
+		categoryContent = sb.String()
+	}
+	categoryPanel := categoryPanelStyle.Render(categoryContent)
+
+	// Import Status Panel (small)
+	importContent := m.renderImportStatus()
+	importPanel := borderStyle.Render(importContent)
+
+	// Recent Transactions Panel
+	recentTransactions := m.budget.GetRecentTransactions(10)
+	transactionsContent := RenderTransactionTable(recentTransactions, m.selectedTransaction)
+	transactionsPanel := transactionsPanelStyle.Render(transactionsContent)
+
+	// Layout panels
+	// Top row: Summary panel
+	topRow := lipgloss.JoinHorizontal(lipgloss.Left, summaryPanel)
+
+	// Middle row: Categories and Import Status
+	middleRow := lipgloss.JoinHorizontal(lipgloss.Left, categoryPanel, " ", importPanel)
+
+	// Bottom row: Transactions
+	bottomRow := lipgloss.JoinHorizontal(lipgloss.Left, transactionsPanel)
+
+	// Combine all rows
+	dashboard := lipgloss.JoinVertical(lipgloss.Top, heroBanner, subtitle, topRow, "\n", middleRow, "\n", bottomRow)
+
+	// Create menu bar at the bottom
+	menuBar := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1).
+		Foreground(blueColor).
+		Render(strings.Join(m.menuChoices, " • "))
+
+	// Add help text if requested
+	var helpText string
+	if m.showHelp {
+		helpText = "\n" + helpStyle.Render("Controls: ↑↓/j/k: Navigate categories | Use shortcuts below menu | h: Toggle help")
+	}
+
+	// Combine dashboard and menu
+	fullDashboard := lipgloss.JoinVertical(lipgloss.Top, dashboard, "\n", menuBar)
+
+	return lipgloss.NewStyle().Padding(1, 0, 0, 0).Render(fullDashboard + helpText)
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// openExplain opens the explain console on a transaction, coming back to
+// the current screen when it closes
+func (m *model) openExplain(t budget.Transaction) {
+	m.explainReturn = m.state
+	m.state = explainState
+	m.explainInputs = [2]string{t.Description, ""}
+	if t.Amount != 0 {
+		m.explainInputs[1] = strconv.FormatFloat(t.Amount, 'f', 2, 64)
+	}
+	m.explainType = t.Type
+	m.explainField = 0
+	m.explainOffset = 0
+	m.explainBase = t
+}
+
+func (m model) updateExplain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = m.explainReturn
+	case tea.KeyTab:
+		m.explainField = (m.explainField + 1) % 3
+	case tea.KeyShiftTab:
+		m.explainField = (m.explainField + 2) % 3
+	case tea.KeyUp:
+		m.explainOffset = max(m.explainOffset-1, 0)
+	case tea.KeyDown:
+		m.explainOffset++
+	case tea.KeyPgUp:
+		m.explainOffset = max(m.explainOffset-reviewPageSize, 0)
+	case tea.KeyPgDown:
+		m.explainOffset += reviewPageSize
+	default:
+		if m.explainField == 2 {
+			if msg.String() == " " {
+				m.explainType = toggleType(m.explainType)
+			}
+			break
+		}
+		m.explainInputs[m.explainField] = editInput(m.explainInputs[m.explainField], msg)
+		m.explainOffset = 0
+	}
+	return m, nil
+}
+
+// ExplanationSummary describes the outcome of an explanation in a few lines
+func ExplanationSummary(e categorizer.Explanation) []string {
+	lines := []string{
+		fmt.Sprintf("Category: %s (%.0f%% confident)", e.Category, e.Confidence*100),
+		"Why: " + e.Reason,
+	}
+	if e.Winner >= 0 {
+		lines = append(lines, fmt.Sprintf("Rules: %s (%.0f%%)", e.RuleCategory, e.RuleConfidence*100))
+	} else {
+		lines = append(lines, "Rules: no match")
+	}
+	if e.LearnedCategory != "" {
+		lines = append(lines, fmt.Sprintf("Learned: %s (%.0f%%)", e.LearnedCategory, e.LearnedConfidence*100))
+	} else {
+		lines = append(lines, "Learned: nothing similar yet")
+	}
+	return lines
+}
+
+// FormatEvaluation describes how one rule fared
+func FormatEvaluation(evaluation categorizer.RuleEvaluation) string {
+	rule := evaluation.Rule
+	if !evaluation.Matched {
+		return fmt.Sprintf("  %3d %-18s %-28s %s", rule.Priority, rule.Category, rule.Pattern, evaluation.SkipReason)
+	}
+	how := evaluation.MatchedBy
+	if evaluation.Keyword != "" {
+		how += fmt.Sprintf(" %q", evaluation.Keyword)
+	}
+	line := fmt.Sprintf("✓ %3d %-18s %-28s %s: %.0f%% → %.0f%%",
+		rule.Priority, rule.Category, rule.Pattern, how, evaluation.BaseConfidence*100, evaluation.Confidence*100)
+	if evaluation.Bonus > 0 {
+		line += fmt.Sprintf(" (+%.0f%% exact match)", evaluation.Bonus*100)
+	}
+	return line
+}
+
+// ExplainOrder lists the rules that matched first, then the rest, both in
+// the order they were tried
+func ExplainOrder(e categorizer.Explanation) []int {
+	var matched, skipped []int
+	for i, evaluation := range e.Evaluations {
+		if evaluation.Matched {
+			matched = append(matched, i)
+		} else {
+			skipped = append(skipped, i)
+		}
+	}
+	return append(matched, skipped...)
+}
+
+func (m model) viewExplain() string {
+	title := titleStyle.Render("🔎 Explain Category")
+	var content strings.Builder
+
+	values := []string{m.explainInputs[0], m.explainInputs[1], string(m.explainType)}
+	for i, label := range []string{"Description", "Amount", "Type"} {
+		prefix := " "
+		value := values[i]
+		if i == m.explainField {
+			prefix = ">"
+			if i < 2 {
+				value += "█"
+			}
+		}
+		content.WriteString(fmt.Sprintf("%s %-12s %s\n", prefix, label+":", value))
+	}
+	content.WriteString("\n")
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(m.explainInputs[1]), 64)
+	if err != nil && strings.TrimSpace(m.explainInputs[1]) != "" {
+		content.WriteString(negativeStyle.Render("Amount must be a number") + "\n")
+	}
+	t := m.explainBase
+	t.Description, t.OriginalDescription = m.explainInputs[0], ""
+	t.Amount, t.Type = math.Abs(amount), m.explainType
+	e := m.categorizer.Explain(t)
+	for _, line := range ExplanationSummary(e) {
+		content.WriteString(line + "\n")
+	}
+
+	order := ExplainOrder(e)
+	offset := min(m.explainOffset, max(len(order)-reviewPageSize, 0))
+	content.WriteString(fmt.Sprintf("\nRules tried (%d-%d of %d):\n", offset+1, min(offset+reviewPageSize, len(order)), len(order)))
+	for _, i := range order[offset:min(offset+reviewPageSize, len(order))] {
+		line := FormatEvaluation(e.Evaluations[i])
+		switch {
+		case i == e.Winner:
+			line = positiveStyle.Render(line + "  ← winner")
+		case e.Evaluations[i].Matched:
+			line = neutralStyle.Render(line)
+		default:
+			line = helpStyle.Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+
+	nav := helpStyle.Render("Tab: Field • Space: Income/expense • ↑↓/PgUp/PgDn: Scroll rules • esc: Back")
+	return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+}
@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FileEntry struct {
+	Name    string
+	Path    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// FileBrowser lists a directory's subdirectories and the files with one of
+// the accepted extensions
+type FileBrowser struct {
+	Dir        string
+	Entries    []FileEntry
+	Cursor     int
+	Extensions []string
+	Marked     map[string]bool // files picked for a batch import, by path
+	Err        error
+}
+
+func NewFileBrowser(dir string, extensions []string) *FileBrowser {
+	browser := &FileBrowser{Extensions: extensions, Marked: make(map[string]bool)}
+	browser.Open(dir)
+	return browser
+}
+
+// Open switches the browser to dir and lists its contents
+func (b *FileBrowser) Open(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	b.Dir = dir
+	b.Cursor = 0
+	b.Load()
+}
+
+// Load re-reads the current directory
+func (b *FileBrowser) Load() {
+	b.Entries = []FileEntry{}
+	b.Err = nil
+
+	if parent := filepath.Dir(b.Dir); parent != b.Dir {
+		b.Entries = append(b.Entries, FileEntry{Name: "..", Path: parent, IsDir: true})
+	}
+
+	dirEntries, err := os.ReadDir(b.Dir)
+	if err != nil {
+		b.Err = err
+		return
+	}
+
+	var dirs, files []FileEntry
+	for _, entry := range dirEntries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		fileEntry := FileEntry{
+			Name:    entry.Name(),
+			Path:    filepath.Join(b.Dir, entry.Name()),
+			IsDir:   info.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+
+		if fileEntry.IsDir {
+			dirs = append(dirs, fileEntry)
+		} else if b.accepts(entry.Name()) {
+			files = append(files, fileEntry)
+		}
+	}
+
+	sort.Slice(dirs, func(i, j int) bool { return strings.ToLower(dirs[i].Name) < strings.ToLower(dirs[j].Name) })
+	// Newest statements first
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.After(files[j].ModTime) })
+
+	b.Entries = append(b.Entries, dirs...)
+	b.Entries = append(b.Entries, files...)
+
+	if b.Cursor >= len(b.Entries) {
+		b.Cursor = max(len(b.Entries)-1, 0)
+	}
+}
+
+func (b *FileBrowser) accepts(name string) bool {
+	if len(b.Extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, accepted := range b.Extensions {
+		if ext == accepted {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *FileBrowser) Up() {
+	if b.Cursor > 0 {
+		b.Cursor--
+	}
+}
+
+func (b *FileBrowser) Down() {
+	if b.Cursor < len(b.Entries)-1 {
+		b.Cursor++
+	}
+}
+
+// Parent moves to the parent directory
+func (b *FileBrowser) Parent() {
+	b.Open(filepath.Dir(b.Dir))
+}
+
+func (b *FileBrowser) Selected() *FileEntry {
+	if b.Cursor < len(b.Entries) {
+		return &b.Entries[b.Cursor]
+	}
+	return nil
+}
+
+// ToggleMark marks or unmarks the selected file. Marks are kept when moving
+// between directories.
+func (b *FileBrowser) ToggleMark() {
+	entry := b.Selected()
+	if entry == nil || entry.IsDir {
+		return
+	}
+	if b.Marked[entry.Path] {
+		delete(b.Marked, entry.Path)
+	} else {
+		b.Marked[entry.Path] = true
+	}
+}
+
+// MarkedPaths returns the marked files in sorted order
+func (b *FileBrowser) MarkedPaths() []string {
+	paths := make([]string, 0, len(b.Marked))
+	for path := range b.Marked {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (b *FileBrowser) ClearMarks() {
+	b.Marked = make(map[string]bool)
+}
+
+// Enter opens the selected directory, or returns the selected file's path
+func (b *FileBrowser) Enter() (string, bool) {
+	entry := b.Selected()
+	if entry == nil {
+		return "", false
+	}
+	if entry.IsDir {
+		b.Open(entry.Path)
+		return "", false
+	}
+	return entry.Path, true
+}
+
+// View renders up to height entries around the cursor
+func (b *FileBrowser) View(height int) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("📂 %s\n\n", b.Dir))
+
+	if b.Err != nil {
+		sb.WriteString(negativeStyle.Render(fmt.Sprintf("Cannot read directory: %v", b.Err)) + "\n")
+	}
+	if len(b.Entries) == 0 || (len(b.Entries) == 1 && b.Entries[0].Name == "..") {
+		sb.WriteString(helpStyle.Render(fmt.Sprintf("No %s files here", strings.Join(b.Extensions, "/"))) + "\n")
+	}
+
+	start := 0
+	if b.Cursor >= height {
+		start = b.Cursor - height + 1
+	}
+	end := min(start+height, len(b.Entries))
+
+	for i := start; i < end; i++ {
+		entry := b.Entries[i]
+
+		cursor := " "
+		if i == b.Cursor {
+			cursor = ">"
+		}
+
+		if entry.IsDir {
+			sb.WriteString(fmt.Sprintf("%s 📁 %s/\n", cursor, entry.Name))
+			continue
+		}
+
+		mark := " "
+		if b.Marked[entry.Path] {
+			mark = "*"
+		}
+
+		name := Truncate(entry.Name, 32, "...")
+		sb.WriteString(fmt.Sprintf("%s%s📄 %-32s %9s  %s\n",
+			cursor,
+			mark,
+			name,
+			FormatFileSize(entry.Size),
+			entry.ModTime.Format("2006-01-02 15:04")))
+	}
+
+	if end < len(b.Entries) {
+		sb.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more", len(b.Entries)-end)) + "\n")
+	}
+	if len(b.Marked) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d files marked\n", len(b.Marked)))
+	}
+
+	return sb.String()
+}
+
+func FormatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,290 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/importer"
+)
+
+func (m model) updateImportHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.historySearching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.historySearching = false
+		case tea.KeyEsc:
+			m.historySearching = false
+			m.historyQuery = ""
+		default:
+			m.historyQuery = editInput(m.historyQuery, msg)
+			m.historyCursor = 0
+		}
+		return m, nil
+	}
+
+	if m.confirmUndo {
+		if session := m.selectedSession(); msg.String() == "y" && session != nil {
+			removed, err := m.budget.RemoveImportSession(session.ID)
+			if err != nil {
+				m.importStatus = fmt.Sprintf("cannot revert %s: %v", session.FileName, err)
+				m.confirmUndo = false
+				return m, nil
+			}
+			m.budget.Save()
+			m.importHistory.MarkReverted(session.ID)
+			m.importHistory.Save()
+			m.importStatus = fmt.Sprintf("reverted %s: removed %d transactions", session.FileName, removed)
+		}
+		m.confirmUndo = false
+		return m, nil
+	}
+
+	if m.historyDetailID != "" {
+		return m.updateImportSessionDetail(msg)
+	}
+
+	sessions := m.importHistory.Search(m.historyQuery)
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+	case "up", "k":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case "down", "j":
+		if m.historyCursor < len(sessions)-1 {
+			m.historyCursor++
+		}
+	case "/":
+		m.historySearching = true
+	case "enter":
+		if m.historyCursor < len(sessions) {
+			session := sessions[m.historyCursor]
+			m.historyDetailID = session.ID
+			m.rerunFormat = 0
+			for i, format := range importer.Formats() {
+				if format.Name == session.Source {
+					m.rerunFormat = i
+				}
+			}
+		}
+	case "u":
+		if session := m.selectedSession(); session != nil && session.Status == "imported" {
+			m.confirmUndo = true
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateImportSessionDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	session := m.selectedSession()
+	if session == nil {
+		m.historyDetailID = ""
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.historyDetailID = ""
+	case "u":
+		if session.Status == "imported" {
+			m.confirmUndo = true
+		}
+	case "f":
+		m.rerunFormat = (m.rerunFormat + 1) % len(importer.Formats())
+	case "r":
+		format := importer.Formats()[m.rerunFormat]
+		m.importEncoding = importer.EncodingAuto
+		m.importLocale = configLocale(m.config)
+		m.importAccount = session.Account
+
+		// Batch sessions re-run every file with the chosen format
+		if len(session.Files) > 0 {
+			return m, m.startBatchImport(session.Paths(), &format)
+		}
+
+		// Re-run the session's file with the chosen format, noting whether
+		// it has changed since
+		m.rerunFileHash = session.FileHash
+		return m, m.startImport(session.FileName, &format)
+	}
+	return m, nil
+}
+
+// selectedSession returns the session open in the detail view, or the one
+// under the cursor in the history list
+func (m model) selectedSession() *importer.ImportSession {
+	if m.historyDetailID != "" {
+		return m.importHistory.FindSession(m.historyDetailID)
+	}
+
+	sessions := m.importHistory.Search(m.historyQuery)
+	if m.historyCursor < len(sessions) {
+		return sessions[m.historyCursor]
+	}
+	return nil
+}
+
+func (m model) viewImportHistory() string {
+	if m.historyDetailID != "" {
+		return m.viewImportSessionDetail()
+	}
+
+	title := titleStyle.Render("🗂  Import History")
+
+	var content strings.Builder
+	sessions := m.importHistory.Search(m.historyQuery)
+
+	if m.historySearching || m.historyQuery != "" {
+		cursor := ""
+		if m.historySearching {
+			cursor = "█"
+		}
+		content.WriteString(fmt.Sprintf("Search: %s%s\n\n", m.historyQuery, cursor))
+	}
+
+	if len(m.importHistory.Sessions) == 0 {
+		content.WriteString("No imports yet.\n")
+	} else if len(sessions) == 0 {
+		content.WriteString("No imports match your search.\n")
+	}
+
+	for i, session := range sessions {
+		cursor := " "
+		if i == m.historyCursor {
+			cursor = ">"
+		}
+
+		content.WriteString(fmt.Sprintf("%s %s  %-30s %s  %d imported, %d skipped\n",
+			cursor,
+			session.Timestamp,
+			session.FileName,
+			renderSessionStatus(session.Status),
+			session.Imported,
+			session.Skipped))
+	}
+
+	if session := m.selectedSession(); m.confirmUndo && session != nil {
+		content.WriteString("\n" + neutralStyle.Render(fmt.Sprintf("Remove the %d transactions imported from %s? (y/n)", session.Imported, session.FileName)) + "\n")
+	}
+
+	content.WriteString(fmt.Sprintf("\nStatus: %s\n", m.importStatus))
+
+	nav := helpStyle.Render("↑↓/j/k: Navigate • Enter: Details • /: Search • u: Undo this import • q/esc: Back to dashboard")
+
+	panel := borderStyle.Render(content.String())
+
+	return lipgloss.JoinVertical(lipgloss.Top, title, panel, nav)
+}
+
+func (m model) viewImportSessionDetail() string {
+	title := titleStyle.Render("🗂  Import Details")
+
+	session := m.selectedSession()
+	if session == nil {
+		return title
+	}
+
+	var content strings.Builder
+
+	if len(session.Files) > 0 {
+		content.WriteString(fmt.Sprintf("Files: %d\n", len(session.Files)))
+		content.WriteString(renderFileSummaries(session.Files))
+	} else {
+		content.WriteString(fmt.Sprintf("File: %s\n", session.FileName))
+		content.WriteString(fmt.Sprintf("Hash: %s\n", session.FileHash))
+	}
+	content.WriteString(fmt.Sprintf("Format: %s\n", session.Source))
+	content.WriteString(fmt.Sprintf("Encoding: %s\n", session.Encoding))
+	content.WriteString(fmt.Sprintf("Imported at: %s\n", session.Timestamp))
+	if session.DateFrom != "" {
+		content.WriteString(fmt.Sprintf("Covers: %s to %s\n", session.DateFrom, session.DateTo))
+	}
+	content.WriteString(fmt.Sprintf("Status: %s\n", renderSessionStatus(session.Status)))
+	if session.RevertedAt != "" {
+		content.WriteString(fmt.Sprintf("Reverted at: %s\n", session.RevertedAt))
+	}
+	content.WriteString(fmt.Sprintf("Rows: %d parsed, %d imported, %d skipped, %d errors\n",
+		session.TotalCount, session.Imported, session.Skipped, len(session.Errors)))
+
+	if len(session.Errors) > 0 {
+		content.WriteString("\nErrors:\n")
+		for _, err := range session.Errors {
+			content.WriteString(fmt.Sprintf("  - %s\n", err))
+		}
+	}
+
+	// Transactions this session created that are still in the budget
+	var created []budget.Transaction
+	for _, t := range m.budget.Transactions {
+		if t.ImportSessionID == session.ID {
+			created = append(created, t)
+		}
+	}
+
+	content.WriteString(fmt.Sprintf("\nTransactions created: %d\n", len(created)))
+	maxDisplay := min(10, len(created))
+	for _, t := range created[:maxDisplay] {
+		content.WriteString(fmt.Sprintf("  %s $%8.2f %-24s %s\n", t.Date.Format("2006-01-02"), t.Amount, t.Description, t.Category))
+	}
+	if len(created) > maxDisplay {
+		content.WriteString(fmt.Sprintf("  ... and %d more\n", len(created)-maxDisplay))
+	}
+
+	content.WriteString(fmt.Sprintf("\nRe-run with format: %s\n", importer.Formats()[m.rerunFormat].Name))
+
+	if m.confirmUndo {
+		content.WriteString("\n" + neutralStyle.Render(fmt.Sprintf("Remove the %d transactions imported from %s? (y/n)", session.Imported, session.FileName)) + "\n")
+	}
+
+	if m.importUpdates != nil {
+		content.WriteString("\n" + m.renderImportProgress())
+	} else {
+		content.WriteString(fmt.Sprintf("\n%s\n", m.importStatus))
+	}
+
+	nav := helpStyle.Render("f: Change format • r: Re-run import • u: Undo this import • q/esc: Back to history")
+
+	panel := borderStyle.Render(content.String())
+
+	return lipgloss.JoinVertical(lipgloss.Top, title, panel, nav)
+}
+
+// renderFileSummaries lists each file of a batch import with its counts
+func renderFileSummaries(files []importer.FileSummary) string {
+	var sb strings.Builder
+	for _, file := range files {
+		name := filepath.Base(file.Path)
+		if file.Failed() {
+			sb.WriteString(negativeStyle.Render(fmt.Sprintf("  ✗ %s: %s", name, file.Errors[0])) + "\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %s (%s): %d/%d rows",
+			name, file.Format, file.SuccessCount, file.TotalRows))
+		if file.Duplicates > 0 {
+			sb.WriteString(fmt.Sprintf(", %d duplicates", file.Duplicates))
+		}
+		if len(file.Errors) > 0 {
+			sb.WriteString(negativeStyle.Render(fmt.Sprintf(", %d errors", len(file.Errors))))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func renderSessionStatus(status string) string {
+	switch status {
+	case "imported":
+		return positiveStyle.Render(status)
+	case "reverted", "error":
+		return negativeStyle.Render(status)
+	default:
+		return status
+	}
+}
@@ -0,0 +1,450 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/config"
+	"github.com/Elwdipath/budget_tui/internal/importer"
+)
+
+// configLocale is the locale the config names for imports, or empty when it
+// names none the importer knows
+func configLocale(cfg *config.Config) string {
+	if _, ok := importer.LookupLocale(cfg.Locale); ok {
+		return cfg.Locale
+	}
+	return ""
+}
+
+func (m *model) resetImportState() {
+	m.importFilePath = ""
+	m.pathInputFocused = false
+	m.fileBrowser = NewFileBrowser(m.config.GetDownloadsDir(), importer.SupportedExtensions)
+	m.importEncoding = importer.EncodingAuto
+	m.importLocale = configLocale(m.config)
+	m.importAccount = ""
+	m.accountInputFocused = false
+	m.importFormat = nil
+	m.importResult = nil
+	m.importSession = nil
+	m.importErr = nil
+	m.selectedPreview = 0
+	m.showImportDetails = false
+	m.reviewEditField = ""
+	m.reviewInput = ""
+	m.showImportErrors = false
+	m.selectedError = 0
+	m.importStatus = "ready"
+}
+
+func (m model) updateImportState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pathInputFocused {
+		return m.updateImportPathInput(msg)
+	}
+	if m.accountInputFocused {
+		return m.updateImportAccountInput(msg)
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+		m.resetImportState()
+	case "up", "k":
+		m.fileBrowser.Up()
+	case "down", "j":
+		m.fileBrowser.Down()
+	case "backspace", "left", "h":
+		m.fileBrowser.Parent()
+	case " ":
+		m.fileBrowser.ToggleMark()
+		m.fileBrowser.Down()
+	case "enter", "right", "l":
+		if marked := m.fileBrowser.MarkedPaths(); len(marked) > 0 && msg.String() == "enter" {
+			return m, m.startBatchImport(marked, nil)
+		} else if path, ok := m.fileBrowser.Enter(); ok {
+			return m, m.startImport(path, nil)
+		}
+	case "~":
+		m.fileBrowser.Open(m.config.GetDownloadsDir())
+	case "tab":
+		// Switch to typing or pasting a path
+		m.pathInputFocused = true
+	case "ctrl+a":
+		m.accountInputFocused = true
+	case "ctrl+e":
+		// Cycle the character encoding override
+		for i, enc := range importer.Encodings {
+			if enc == m.importEncoding {
+				m.importEncoding = importer.Encodings[(i+1)%len(importer.Encodings)]
+				break
+			}
+		}
+	case "ctrl+l":
+		// Cycle the locale override, starting from the format's own
+		names := []string{""}
+		for _, locale := range importer.Locales {
+			names = append(names, locale.Name)
+		}
+		next := slices.Index(names, m.importLocale) + 1
+		m.importLocale = names[next%len(names)]
+	}
+	return m, nil
+}
+
+// updateImportPathInput handles typing or pasting a path on the import
+// screen. A directory opens in the file browser, a file is imported.
+func (m model) updateImportPathInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyTab:
+		m.pathInputFocused = false
+	case tea.KeyEnter:
+		path := config.ExpandHome(strings.TrimSpace(m.importFilePath))
+		if path == "" {
+			return m, nil
+		}
+
+		// A glob such as ~/statements/*.csv imports every match at once
+		if strings.ContainsAny(path, "*?[") {
+			paths, err := importer.ExpandPaths([]string{path})
+			if err != nil {
+				m.importStatus = "error: " + err.Error()
+			} else {
+				return m, m.startBatchImport(paths, nil)
+			}
+			return m, nil
+		}
+
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			m.importStatus = "error: " + err.Error()
+		case info.IsDir():
+			m.fileBrowser.Open(path)
+			m.importFilePath = ""
+			m.pathInputFocused = false
+		default:
+			return m, m.startImport(path, nil)
+		}
+	default:
+		m.importFilePath = editInput(m.importFilePath, msg)
+	}
+	return m, nil
+}
+
+// updateImportAccountInput handles typing the account the import is for.
+// Tab completes one of the accounts already in the budget.
+func (m model) updateImportAccountInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyEnter:
+		m.importAccount = strings.TrimSpace(m.importAccount)
+		m.accountInputFocused = false
+	case tea.KeyTab:
+		m.importAccount = completeCategory(m.budget.Accounts(), m.importAccount)
+	default:
+		m.importAccount = editInput(m.importAccount, msg)
+	}
+	return m, nil
+}
+
+// importProgressMsg reports how far the background import has got
+type importProgressMsg struct {
+	progress importer.ImportProgress
+	updates  chan tea.Msg
+}
+
+// importDoneMsg carries a prepared import back from the background
+type importDoneMsg struct {
+	session *importer.ImportSession
+	result  *importer.ImportResult
+	err     error
+	updates chan tea.Msg
+}
+
+type spinnerTickMsg struct{}
+
+func spinnerTick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return spinnerTickMsg{}
+	})
+}
+
+// prepareFunc does the work of an import, stopping when ctx is canceled
+type prepareFunc func(ctx context.Context, onProgress func(importer.ImportProgress)) (*importer.ImportSession, *importer.ImportResult, error)
+
+// runImport runs prepare in the background. Progress and the prepared
+// import come back as messages, and Esc cancels it.
+func (m *model) runImport(prepare prepareFunc) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.importCancel = cancel
+	updates := make(chan tea.Msg, 16)
+	m.importUpdates = updates
+	m.importProgress = importer.ImportProgress{Stage: importer.StageDetect}
+	m.importErr = nil
+	m.spinnerFrame = 0
+
+	run := func() tea.Msg {
+		go func() {
+			session, result, err := prepare(ctx, func(p importer.ImportProgress) {
+				// Drop updates rather than slow the import when the UI is behind
+				select {
+				case updates <- importProgressMsg{progress: p, updates: updates}:
+				default:
+				}
+			})
+			updates <- importDoneMsg{session: session, result: result, err: err, updates: updates}
+			close(updates)
+		}()
+		return <-updates
+	}
+	return tea.Batch(run, spinnerTick())
+}
+
+// waitForImport delivers the next message from a background import
+func waitForImport(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// startImport parses filePath in the background and opens the review screen
+// when it is done. When format is nil the format is detected from the file.
+func (m *model) startImport(filePath string, format *importer.CSVFormat) tea.Cmd {
+	m.importStatus = "importing"
+	m.importFilePath = filePath
+	m.retryPaths = []string{filePath}
+	m.retryFormat = format
+
+	enc, account := m.importEncoding, m.importAccount
+	locale, _ := importer.LookupLocale(m.importLocale)
+	existing := make([]budget.Transaction, len(m.budget.Transactions))
+	copy(existing, m.budget.Transactions)
+	c := m.categorizer
+
+	return m.runImport(func(ctx context.Context, onProgress func(importer.ImportProgress)) (*importer.ImportSession, *importer.ImportResult, error) {
+		return importer.PrepareImport(ctx, filePath, format, enc, locale, account, existing, c, onProgress)
+	})
+}
+
+// startBatchImport parses several files concurrently in the background and
+// opens them in the review screen as one session
+func (m *model) startBatchImport(paths []string, format *importer.CSVFormat) tea.Cmd {
+	if len(paths) == 1 {
+		return m.startImport(paths[0], format)
+	}
+
+	m.importStatus = "importing"
+	m.importFilePath = ""
+	m.retryPaths = paths
+	m.retryFormat = format
+
+	enc, account := m.importEncoding, m.importAccount
+	locale, _ := importer.LookupLocale(m.importLocale)
+	existing := make([]budget.Transaction, len(m.budget.Transactions))
+	copy(existing, m.budget.Transactions)
+	c := m.categorizer
+
+	return m.runImport(func(ctx context.Context, onProgress func(importer.ImportProgress)) (*importer.ImportSession, *importer.ImportResult, error) {
+		return importer.PrepareBatchImport(ctx, paths, format, enc, locale, account, existing, c, onProgress)
+	})
+}
+
+// finishImport opens the review screen for a prepared import. A failed
+// import opens it too, showing what went wrong; a canceled one just stops.
+func (m *model) finishImport(session *importer.ImportSession, result *importer.ImportResult, err error) {
+	m.importCancel()
+	m.importCancel = nil
+	m.importUpdates = nil
+	m.importSession = session
+
+	if err != nil {
+		var importErr *importer.ImportError
+		if !errors.As(err, &importErr) {
+			importErr = &importer.ImportError{File: m.importFilePath, Err: err}
+		}
+		m.rerunFileHash = ""
+		if importErr.Canceled() {
+			m.importStatus = "import canceled"
+			return
+		}
+
+		m.importErr = importErr
+		m.importResult = nil
+		m.historyDetailID = ""
+		m.state = reviewState
+		m.importStatus = "import failed"
+		return
+	}
+
+	m.importResult = result
+	m.importFormat = &result.Format
+	m.selectedPreview = 0
+	m.fileBrowser.ClearMarks()
+	m.historyDetailID = ""
+
+	m.state = reviewState
+	m.importStatus = "ready for review"
+	if m.rerunFileHash != "" && session.FileHash != m.rerunFileHash {
+		m.importStatus = "ready for review (file has changed since the original import)"
+	}
+	m.rerunFileHash = ""
+}
+
+func (m model) viewImportState() string {
+	title := titleStyle.Render("📁 Import Bank Statement")
+
+	var content strings.Builder
+
+	content.WriteString("Import CSV bank statements to automatically categorize transactions.\n\n")
+
+	// File browser
+	content.WriteString(m.fileBrowser.View(fileBrowserHeight))
+
+	// File path input
+	content.WriteString("\nFile Path:\n")
+	if m.pathInputFocused {
+		content.WriteString(fmt.Sprintf("  > %s█\n", m.importFilePath))
+	} else {
+		content.WriteString("  Press Tab to type or paste a path\n")
+	}
+
+	// Encoding, locale and account
+	content.WriteString(fmt.Sprintf("\nEncoding: %s\n", m.importEncoding))
+	if m.importLocale == "" {
+		content.WriteString("Locale:   the format's own\n")
+	} else {
+		content.WriteString(fmt.Sprintf("Locale:   %s\n", m.importLocale))
+	}
+	switch {
+	case m.accountInputFocused:
+		content.WriteString(fmt.Sprintf("Account:  > %s█\n", m.importAccount))
+	case m.importAccount != "":
+		content.WriteString(fmt.Sprintf("Account:  %s\n", m.importAccount))
+	default:
+		content.WriteString("Account:  none (Ctrl+A to set)\n")
+	}
+
+	// Status
+	if m.importUpdates != nil {
+		content.WriteString("\n" + m.renderImportProgress())
+	} else {
+		content.WriteString(fmt.Sprintf("\nStatus: %s\n", m.importStatus))
+	}
+
+	// Navigation
+	nav := helpStyle.Render("↑↓/j/k: Navigate • Space: Mark file • Enter: Open/import • Backspace: Up • ~: Downloads • Tab: Type path • Ctrl+E: Encoding • Ctrl+L: Locale • Ctrl+A: Account • q/esc: Back")
+	switch {
+	case m.pathInputFocused:
+		nav = helpStyle.Render("Enter: Import file or glob, or open directory • Tab/esc: Back to file browser")
+	case m.accountInputFocused:
+		nav = helpStyle.Render("Tab: Complete account • Enter/esc: Done")
+	}
+
+	panel := borderStyle.Render(content.String())
+
+	return lipgloss.JoinVertical(lipgloss.Top, title, panel, nav)
+}
+
+// renderImportProgress shows what the background import is doing
+func (m model) renderImportProgress() string {
+	p := m.importProgress
+	spinner := SpinnerFrames[m.spinnerFrame%len(SpinnerFrames)]
+	name := filepath.Base(p.File)
+
+	var line string
+	switch {
+	case p.Stage == importer.StageParse && p.Total > 0:
+		line = fmt.Sprintf("Parsing files: %d of %d done (last: %s)", p.Done, p.Total, name)
+	case p.Stage == importer.StageParse:
+		line = fmt.Sprintf("Parsing %s as %s: %d rows", name, p.Format, p.Rows)
+	case p.Stage == importer.StageCategorize:
+		line = fmt.Sprintf("Categorizing %s: %d of %d rows", name, p.Done, p.Total)
+	default:
+		line = fmt.Sprintf("Detecting format of %s", filepath.Base(m.importFilePath))
+		if m.importFilePath == "" {
+			line = fmt.Sprintf("Reading %d files", len(m.retryPaths))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n", spinner, line))
+	sb.WriteString(fmt.Sprintf("%s %3.0f%%\n", RenderProgressBar(p.Fraction(), 30), p.Fraction()*100))
+	if m.importStatus == "canceling import..." {
+		sb.WriteString(neutralStyle.Render(m.importStatus) + "\n")
+	} else {
+		sb.WriteString(helpStyle.Render("esc: Cancel") + "\n")
+	}
+	return sb.String()
+}
+
+// renderImportError explains why an import failed
+func (m model) renderImportError() string {
+	e := m.importErr
+
+	var sb strings.Builder
+	sb.WriteString(negativeStyle.Render("❌ Import failed") + "\n\n")
+	if e.File != "" {
+		sb.WriteString(fmt.Sprintf("File:  %s\n", e.File))
+	}
+	if e.Stage != "" {
+		sb.WriteString(fmt.Sprintf("Stage: %s\n", e.Stage))
+	}
+	sb.WriteString(fmt.Sprintf("Error: %v\n", e.Err))
+	if m.importSession != nil && len(m.importSession.Errors) > 0 {
+		sb.WriteString("\n")
+		for _, err := range m.importSession.Errors {
+			sb.WriteString(fmt.Sprintf("  - %s\n", err))
+		}
+	}
+	if hint := e.Hint(); hint != "" {
+		sb.WriteString("\n" + neutralStyle.Render(hint) + "\n")
+	}
+	sb.WriteString("\n" + helpStyle.Render("r: Retry • q/esc: Back to import"))
+	return sb.String()
+}
+
+func (m model) renderImportStatus() string {
+	var sb strings.Builder
+
+	sb.WriteString("📊 Import Status\n\n")
+
+	if pending := len(m.pendingQueue.Items); pending > 0 {
+		sb.WriteString(neutralStyle.Render(fmt.Sprintf("📥 %d waiting for review [p]", pending)) + "\n\n")
+	}
+
+	if m.importHistory != nil && len(m.importHistory.Sessions) > 0 {
+		lastSession := m.importHistory.GetLastSession()
+		sb.WriteString(fmt.Sprintf("Last Import: %s\n", lastSession.Timestamp))
+		sb.WriteString(fmt.Sprintf("File: %s\n", lastSession.FileName))
+		sb.WriteString(fmt.Sprintf("Status: %s\n", lastSession.Status))
+		sb.WriteString(fmt.Sprintf("Imported: %d transactions\n", lastSession.Imported))
+
+		if lastSession.Status == "imported" {
+			sb.WriteString("\n" + positiveStyle.Render("✅ Import completed successfully"))
+		} else if lastSession.Status == "error" {
+			sb.WriteString("\n" + negativeStyle.Render("❌ Last import had errors"))
+		}
+	} else {
+		sb.WriteString("No imports yet\n")
+		sb.WriteString("\nPress [b] to import your first bank statement")
+	}
+
+	if m.config.WatchDir != "" {
+		sb.WriteString(fmt.Sprintf("\n\nWatching: %s", m.config.WatchDir))
+		if m.watchStatus != "" {
+			sb.WriteString("\n" + helpStyle.Render(m.watchStatus))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,16 @@
+package tui
+
+import (
+	"strings"
+)
+
+// SpinnerFrames are shown one after another while work runs in the
+// background
+var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// RenderProgressBar draws fraction (0 to 1) as a bar width cells wide
+func RenderProgressBar(fraction float64, width int) string {
+	fraction = min(max(fraction, 0), 1)
+	filled := int(fraction * float64(width))
+	return positiveStyle.Render(strings.Repeat("█", filled)) + helpStyle.Render(strings.Repeat("░", width-filled))
+}
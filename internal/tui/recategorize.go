@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// recatFilter reads the recategorize filter from its inputs
+func (m model) recatFilter() (categorizer.RecategorizeFilter, error) {
+	return ParseRecategorizeFilter(m.recatInputs, m.recatProtect)
+}
+
+// ParseRecategorizeFilter reads a recategorize filter from the from and to
+// dates, source and current category the user typed
+func ParseRecategorizeFilter(inputs [4]string, protectManual bool) (categorizer.RecategorizeFilter, error) {
+	filter := categorizer.RecategorizeFilter{
+		Source:        strings.TrimSpace(inputs[2]),
+		Category:      strings.TrimSpace(inputs[3]),
+		ProtectManual: protectManual,
+	}
+	for i, date := range []*time.Time{&filter.From, &filter.To} {
+		value := strings.TrimSpace(inputs[i])
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("dates look like 2024-01-31, not %q", value)
+		}
+		*date = parsed
+	}
+	return filter, nil
+}
+
+func (m model) updateRecategorize(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.recatPlanned {
+		return m.updateRecategorizePlan(msg)
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = dashboardState
+	case tea.KeyTab, tea.KeyDown:
+		m.recatField = (m.recatField + 1) % (recatProtectField + 1)
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.recatField = (m.recatField + recatProtectField) % (recatProtectField + 1)
+	case tea.KeyEnter:
+		filter, err := m.recatFilter()
+		if err != nil {
+			m.recatStatus = err.Error()
+			break
+		}
+		m.recatChanges = m.categorizer.PlanRecategorize(m.budget.Transactions, filter)
+		m.recatExcluded = make(map[int]bool)
+		m.recatCursor = 0
+		m.recatPlanned = true
+		m.recatStatus = fmt.Sprintf("dry run: %d transactions would change", len(m.recatChanges))
+	default:
+		if m.recatField == recatProtectField {
+			if msg.String() == " " {
+				m.recatProtect = !m.recatProtect
+			}
+			break
+		}
+		m.recatInputs[m.recatField] = editInput(m.recatInputs[m.recatField], msg)
+	}
+	return m, nil
+}
+
+// updateRecategorizePlan handles the dry run's list of changes
+func (m model) updateRecategorizePlan(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.recatPlanned = false
+		m.recatStatus = "nothing changed"
+	case "up", "k":
+		if m.recatCursor > 0 {
+			m.recatCursor--
+		}
+	case "down", "j":
+		if m.recatCursor < len(m.recatChanges)-1 {
+			m.recatCursor++
+		}
+	case " ":
+		if m.recatCursor < len(m.recatChanges) {
+			m.recatExcluded[m.recatCursor] = !m.recatExcluded[m.recatCursor]
+			if m.recatCursor < len(m.recatChanges)-1 {
+				m.recatCursor++
+			}
+		}
+	case "A":
+		var changes, rejected []categorizer.CategoryChange
+		for i, change := range m.recatChanges {
+			if m.recatExcluded[i] {
+				rejected = append(rejected, change)
+			} else {
+				changes = append(changes, change)
+			}
+		}
+		count := m.categorizer.ApplyRecategorize(m.budget, changes)
+		m.categorizer.RecordRejected(rejected)
+		m.budget.Save()
+		m.recatPlanned = false
+		m.recatStatus = fmt.Sprintf("recategorized %d transactions", count)
+	}
+	return m, nil
+}
+
+func (m model) viewRecategorize() string {
+	title := titleStyle.Render("🔁 Recategorize Transactions")
+
+	var content strings.Builder
+	if !m.recatPlanned {
+		labels := []string{"From (YYYY-MM-DD)", "To (YYYY-MM-DD)", "Source", "Current category"}
+		for i, label := range labels {
+			prefix := " "
+			value := m.recatInputs[i]
+			if i == m.recatField {
+				prefix = ">"
+				value += "█"
+			}
+			content.WriteString(fmt.Sprintf("%s %-18s %s\n", prefix, label+":", value))
+		}
+		prefix := " "
+		if m.recatField == recatProtectField {
+			prefix = ">"
+		}
+		check := "[ ]"
+		if m.recatProtect {
+			check = "[x]"
+		}
+		content.WriteString(fmt.Sprintf("%s %s Protect categories set by hand\n", prefix, check))
+		content.WriteString(helpStyle.Render(fmt.Sprintf("\n  Sources: %s, or a format such as Chase or JSON", categorizer.ManualSource)) + "\n")
+		content.WriteString(fmt.Sprintf("\n%s\n", m.recatStatus))
+
+		nav := helpStyle.Render("Tab/↑↓: Field • Space: Toggle protect • Enter: Dry run • esc: Back")
+		return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+	}
+
+	if len(m.recatChanges) == 0 {
+		content.WriteString("The rules agree with every selected transaction.\n")
+	}
+
+	start := 0
+	if m.recatCursor >= reviewPageSize {
+		start = m.recatCursor - reviewPageSize + 1
+	}
+	end := min(start+reviewPageSize, len(m.recatChanges))
+	for i := start; i < end; i++ {
+		change := m.recatChanges[i]
+		cursor := " "
+		if i == m.recatCursor {
+			cursor = ">"
+		}
+		check := positiveStyle.Render("✓")
+		if m.recatExcluded[i] {
+			check = negativeStyle.Render("✗")
+		}
+		content.WriteString(fmt.Sprintf("%s %s %s %-20s %s → %s %s\n",
+			cursor,
+			check,
+			change.Date.Format("2006-01-02"),
+			Truncate(change.Description, 20, ""),
+			change.OldCategory,
+			change.NewCategory,
+			getConfidenceBar(change.Confidence)))
+	}
+	if end < len(m.recatChanges) {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more", len(m.recatChanges)-end)) + "\n")
+	}
+	content.WriteString(fmt.Sprintf("\n%s\n", m.recatStatus))
+
+	nav := helpStyle.Render("↑↓/j/k: Navigate • Space: Skip/keep change • A: Apply • esc: Back to filters")
+	return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+}
@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// reconcileAccountName returns the account being reconciled
+func (m model) reconcileAccountName() string {
+	accounts := m.budget.Accounts()
+	if len(accounts) == 0 {
+		return ""
+	}
+	return accounts[m.reconcileAccount%len(accounts)]
+}
+
+// startReconcile sets up a reconciliation of the selected account, starting
+// from the statement details of its latest import when there are any
+func (m *model) startReconcile() {
+	r, fromImport := m.budget.StatementFromImport(m.reconcileAccountName())
+	m.setReconciliation(r)
+	m.reconcileEditing = true
+	m.reconcileField = 0
+	m.reconcileCursor = 0
+	m.reconcileStatus = "enter the statement's date and balances"
+	if fromImport {
+		m.reconcileStatus = "statement details read from the last import"
+	}
+}
+
+func (m *model) setReconciliation(r budget.Reconciliation) {
+	m.reconcile = r
+	m.reconcileInputs = [3]string{
+		r.StatementDate.Format("2006-01-02"),
+		fmt.Sprintf("%.2f", r.OpeningBalance),
+		fmt.Sprintf("%.2f", r.EndingBalance),
+	}
+}
+
+func (m model) updateReconcile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.reconcileEditing {
+		return m.updateReconcileStatement(msg)
+	}
+
+	candidates := m.budget.ReconcileCandidates(m.reconcile)
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+	case "up", "k":
+		if m.reconcileCursor > 0 {
+			m.reconcileCursor--
+		}
+	case "down", "j":
+		if m.reconcileCursor < len(candidates)-1 {
+			m.reconcileCursor++
+		}
+	case " ", "x":
+		if m.reconcileCursor < len(candidates) {
+			t := m.budget.Transactions[candidates[m.reconcileCursor]]
+			if err := m.budget.ToggleCleared(t.ID); err != nil {
+				m.reconcileStatus = "error: " + err.Error()
+				return m, nil
+			}
+			m.budget.Save()
+			if m.reconcileCursor < len(candidates)-1 {
+				m.reconcileCursor++
+			}
+		}
+	case "e":
+		m.reconcileEditing = true
+	case "F":
+		count, err := m.budget.FinishReconciliation(m.reconcile)
+		if err != nil {
+			m.reconcileStatus = "cannot finish: " + err.Error()
+			return m, nil
+		}
+		m.budget.Save()
+		m.startReconcile()
+		m.reconcileStatus = fmt.Sprintf("reconciled %d transactions", count)
+	}
+	return m, nil
+}
+
+// updateReconcileStatement handles entering the statement date and balances
+func (m model) updateReconcileStatement(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = dashboardState
+	case tea.KeyTab:
+		m.reconcileField = (m.reconcileField + 1) % len(m.reconcileInputs)
+	case tea.KeyShiftTab:
+		m.reconcileField = (m.reconcileField - 1 + len(m.reconcileInputs)) % len(m.reconcileInputs)
+	case tea.KeyLeft, tea.KeyRight:
+		// Switch account
+		accounts := m.budget.Accounts()
+		if len(accounts) > 1 {
+			if msg.Type == tea.KeyLeft {
+				m.reconcileAccount = (m.reconcileAccount - 1 + len(accounts)) % len(accounts)
+			} else {
+				m.reconcileAccount = (m.reconcileAccount + 1) % len(accounts)
+			}
+			m.startReconcile()
+		}
+	case tea.KeyCtrlR:
+		// Reload the statement details from the last import
+		if r, ok := m.budget.StatementFromImport(m.reconcileAccountName()); ok {
+			m.setReconciliation(r)
+			m.reconcileStatus = "statement details read from the last import"
+		} else {
+			m.reconcileStatus = "no imported running balances for this account"
+		}
+	case tea.KeyEnter:
+		r := budget.Reconciliation{Account: m.reconcileAccountName()}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(m.reconcileInputs[0]))
+		if err != nil {
+			m.reconcileStatus = "statement date must be YYYY-MM-DD"
+			return m, nil
+		}
+		r.StatementDate = date
+		if _, err := fmt.Sscanf(m.reconcileInputs[1], "%f", &r.OpeningBalance); err != nil {
+			m.reconcileStatus = "opening balance is not a number"
+			return m, nil
+		}
+		if _, err := fmt.Sscanf(m.reconcileInputs[2], "%f", &r.EndingBalance); err != nil {
+			m.reconcileStatus = "ending balance is not a number"
+			return m, nil
+		}
+
+		m.reconcile = r
+		m.reconcileEditing = false
+		m.reconcileCursor = 0
+		m.reconcileStatus = "tick off the transactions that appear on the statement"
+	default:
+		m.reconcileInputs[m.reconcileField] = editInput(m.reconcileInputs[m.reconcileField], msg)
+	}
+	return m, nil
+}
+
+func (m model) viewReconcile() string {
+	title := titleStyle.Render("🧾 Reconcile Account")
+
+	var content strings.Builder
+
+	account := m.reconcileAccountName()
+	if account == "" {
+		account = "(default)"
+	}
+	content.WriteString(fmt.Sprintf("Account: %s\n", account))
+	if last := m.budget.LastReconciliation(m.reconcileAccountName()); last != nil {
+		content.WriteString(fmt.Sprintf("Last reconciled: %s at %.2f\n",
+			last.StatementDate.Format("2006-01-02"), last.EndingBalance))
+	}
+	content.WriteString("\n")
+
+	if m.reconcileEditing {
+		labels := []string{"Statement date", "Opening balance", "Ending balance"}
+		for i, label := range labels {
+			prefix := " "
+			value := m.reconcileInputs[i]
+			if i == m.reconcileField {
+				prefix = ">"
+				value += "█"
+			}
+			content.WriteString(fmt.Sprintf("%s %-16s %s\n", prefix, label+":", value))
+		}
+		content.WriteString(fmt.Sprintf("\n%s\n", m.reconcileStatus))
+
+		nav := helpStyle.Render("Tab: Next field • ←/→: Account • Ctrl+R: Read from last import • Enter: Start • esc: Back")
+		return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+	}
+
+	cleared := m.budget.ClearedBalance(m.reconcile)
+	difference := m.budget.ReconcileDifference(m.reconcile)
+
+	content.WriteString(fmt.Sprintf("Statement: %s  Opening %.2f  Ending %.2f\n",
+		m.reconcile.StatementDate.Format("2006-01-02"), m.reconcile.OpeningBalance, m.reconcile.EndingBalance))
+	content.WriteString(fmt.Sprintf("Cleared balance: %.2f\n", cleared))
+	if difference == 0 {
+		content.WriteString(positiveStyle.Render("Difference: 0.00 ✓ balanced") + "\n\n")
+	} else {
+		content.WriteString(negativeStyle.Render(fmt.Sprintf("Difference: %.2f", difference)) + "\n\n")
+	}
+
+	candidates := m.budget.ReconcileCandidates(m.reconcile)
+	if len(candidates) == 0 {
+		content.WriteString("No unreconciled transactions up to the statement date.\n")
+	}
+
+	start := 0
+	if m.reconcileCursor >= reviewPageSize {
+		start = m.reconcileCursor - reviewPageSize + 1
+	}
+	end := min(start+reviewPageSize, len(candidates))
+	for i := start; i < end; i++ {
+		t := m.budget.Transactions[candidates[i]]
+
+		cursor := " "
+		if i == m.reconcileCursor {
+			cursor = ">"
+		}
+		check := "[ ]"
+		if t.Status == budget.Cleared {
+			check = positiveStyle.Render("[✓]")
+		}
+
+		content.WriteString(fmt.Sprintf("%s %s %s %-24s %10.2f\n",
+			cursor,
+			check,
+			t.Date.Format("2006-01-02"),
+			Truncate(t.Description, 24, "…"),
+			t.SignedAmount()))
+	}
+	if end < len(candidates) {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more", len(candidates)-end)) + "\n")
+	}
+
+	content.WriteString(fmt.Sprintf("\n%s\n", m.reconcileStatus))
+
+	nav := helpStyle.Render("↑↓/j/k: Navigate • Space/x: Toggle cleared • e: Edit statement • F: Finish • q/esc: Back")
+
+	return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+}
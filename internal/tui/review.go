@@ -0,0 +1,497 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/importer"
+)
+
+func (m model) updateReviewState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.importErr != nil {
+		switch msg.String() {
+		case "r":
+			return m, m.startBatchImport(m.retryPaths, m.retryFormat)
+		case "q", "esc":
+			m.importErr = nil
+			m.state = importState
+			m.importStatus = "ready"
+		}
+		return m, nil
+	}
+
+	if m.reviewEditField == "error" {
+		return m.updateErrorFix(msg)
+	}
+	if m.reviewEditField != "" {
+		return m.updateReviewEdit(msg)
+	}
+	if m.showImportErrors {
+		return m.updateReviewErrors(msg)
+	}
+
+	var decisions []importer.RowDecision
+	if m.importSession != nil {
+		decisions = m.importSession.Decisions
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+		m.resetImportState()
+	case "c":
+		// Confirm and complete import
+		if m.importResult != nil && m.importSession != nil {
+			m.importStatus = "importing"
+
+			// Add accepted transactions to budget, and take them out again
+			// if they can't be saved, so the import can be confirmed again
+			importedCount := importer.ApplyImport(m.budget, m.importSession, m.importResult)
+			if err := m.budget.Save(); err != nil {
+				m.budget.RemoveImportSession(m.importSession.ID)
+				m.importSession.Status = "reviewing"
+				m.importStatus = fmt.Sprintf("error: the budget couldn't be saved: %v", err)
+				return m, nil
+			}
+			imported := m.budget.Transactions[len(m.budget.Transactions)-importedCount:]
+			for _, t := range imported {
+				m.categorizer.Learn(t)
+			}
+			// The rows were reviewed, so they show which suggestions held up
+			m.categorizer.RecordOutcomes(imported)
+
+			// Update import session
+			m.importHistory.AddSession(*m.importSession)
+			err := m.importHistory.Save()
+			if m.pendingQueue.Remove(m.importSession.ID) {
+				err = errors.Join(err, m.pendingQueue.Save())
+			}
+
+			m.resetImportState()
+			if err != nil {
+				// The rows are in the budget, so show what failed on the
+				// import screen rather than the review
+				m.state = importState
+				m.importStatus = fmt.Sprintf("imported %d transactions, but the import history couldn't be saved: %v", importedCount, err)
+				return m, nil
+			}
+			m.state = dashboardState
+			m.importStatus = fmt.Sprintf("imported %d transactions", importedCount)
+		}
+	case "up", "k":
+		if m.selectedPreview > 0 {
+			m.selectedPreview--
+		}
+	case "down", "j":
+		if m.selectedPreview < len(decisions)-1 {
+			m.selectedPreview++
+		}
+	case "pgup":
+		m.selectedPreview = max(m.selectedPreview-reviewPageSize, 0)
+	case "pgdown":
+		m.selectedPreview = max(min(m.selectedPreview+reviewPageSize, len(decisions)-1), 0)
+	case "home", "g":
+		m.selectedPreview = 0
+	case "end", "G":
+		m.selectedPreview = max(len(decisions)-1, 0)
+	case "a", "r", " ":
+		if m.selectedPreview < len(decisions) {
+			d := &decisions[m.selectedPreview]
+			switch {
+			case msg.String() == "a":
+				d.Action = importer.RowAccept
+			case msg.String() == "r":
+				d.Action = importer.RowReject
+			case d.Action == importer.RowAccept:
+				d.Action = importer.RowReject
+			default:
+				d.Action = importer.RowAccept
+			}
+			if m.selectedPreview < len(decisions)-1 {
+				m.selectedPreview++
+			}
+		}
+	case "e":
+		if m.selectedPreview < len(decisions) {
+			m.reviewEditField = "description"
+			m.reviewInput = decisions[m.selectedPreview].Description
+		}
+	case "o":
+		if m.selectedPreview < len(decisions) {
+			m.reviewEditField = "category"
+			m.reviewInput = ""
+		}
+	case "t":
+		if m.selectedPreview < len(decisions) {
+			// Only the flag changes, so the category stays as suggested or
+			// edited and the rule isn't counted as overridden
+			decisions[m.selectedPreview].IsTransfer = !decisions[m.selectedPreview].IsTransfer
+		}
+	case "A":
+		count := importer.AcceptAbove(decisions, bulkConfidence)
+		m.importStatus = fmt.Sprintf("accepted %d rows above %.0f%% confidence", count, bulkConfidence*100)
+	case "R":
+		count := importer.RejectBelow(decisions, bulkConfidence)
+		m.importStatus = fmt.Sprintf("rejected %d rows below %.0f%% confidence", count, bulkConfidence*100)
+	case "d":
+		m.showImportDetails = !m.showImportDetails
+	case "x":
+		if m.importResult != nil && len(m.importResult.Errors) > 0 {
+			m.showImportErrors = true
+			m.selectedError = 0
+		}
+	case "w":
+		m.exportRejectedRows()
+	}
+	return m, nil
+}
+
+// updateReviewErrors handles the list of rows that couldn't be imported
+func (m model) updateReviewErrors(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	errs := m.importResult.Errors
+
+	switch msg.String() {
+	case "q", "esc", "x":
+		m.showImportErrors = false
+	case "up", "k":
+		if m.selectedError > 0 {
+			m.selectedError--
+		}
+	case "down", "j":
+		if m.selectedError < len(errs)-1 {
+			m.selectedError++
+		}
+	case "enter", "e":
+		if m.selectedError >= len(errs) {
+			break
+		}
+		e := errs[m.selectedError]
+		if e.Kind == importer.ErrUnreadableFile {
+			m.importStatus = "the file couldn't be read; fix it and import it again"
+			break
+		}
+		m.reviewEditField = "error"
+		m.errorEditColumn = importer.NoColumn
+		if e.Column != importer.NoColumn && e.Column < len(e.Record) {
+			m.errorEditColumn = e.Column
+			m.reviewInput = e.Record[e.Column]
+		} else {
+			m.reviewInput = m.importResult.FormatOf(e).JoinRecord(e.Record)
+		}
+	case "w":
+		m.exportRejectedRows()
+	}
+	return m, nil
+}
+
+// updateErrorFix handles typing while fixing a row that couldn't be
+// imported. Enter parses the row again; if it parses, it joins the review.
+func (m model) updateErrorFix(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.reviewEditField = ""
+	case tea.KeyEnter:
+		e := m.importResult.Errors[m.selectedError]
+		record := slices.Clone(e.Record)
+		if m.errorEditColumn != importer.NoColumn {
+			record[m.errorEditColumn] = strings.TrimSpace(m.reviewInput)
+		} else {
+			fields, err := m.importResult.FormatOf(e).SplitRecord(m.reviewInput)
+			if err != nil {
+				m.importStatus = fmt.Sprintf("can't split the line: %v", err)
+				return m, nil
+			}
+			record = fields
+		}
+
+		m.reviewEditField = ""
+		row, err := m.importSession.FixRow(m.importResult, m.selectedError, record, m.budget.Transactions, m.categorizer)
+		if err != nil {
+			m.importStatus = fmt.Sprintf("still failing: %v", err)
+			return m, nil
+		}
+
+		m.importStatus = fmt.Sprintf("row %d fixed and added for review", e.Row)
+		m.selectedPreview = row
+		if len(m.importResult.Errors) == 0 {
+			m.showImportErrors = false
+		} else {
+			m.selectedError = min(m.selectedError, len(m.importResult.Errors)-1)
+		}
+	default:
+		m.reviewInput = editInput(m.reviewInput, msg)
+	}
+	return m, nil
+}
+
+// exportRejectedRows writes the rows that couldn't be imported to a CSV
+// file next to the statement so they can be fixed outside the app
+func (m *model) exportRejectedRows() {
+	if m.importResult == nil || m.importSession == nil || len(m.importResult.Errors) == 0 {
+		m.importStatus = "no rejected rows to export"
+		return
+	}
+	path := importer.RejectedRowsPath(m.importSession)
+	if err := m.importResult.ExportRejectedRows(path); err != nil {
+		m.importStatus = fmt.Sprintf("export failed: %v", err)
+		return
+	}
+	m.importStatus = fmt.Sprintf("exported %d rejected rows to %s", len(m.importResult.Errors), path)
+}
+
+// updateReviewEdit handles typing while editing a row's description or
+// category during review
+func (m model) updateReviewEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.importSession.Decisions[m.selectedPreview]
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.reviewEditField = ""
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.reviewInput)
+		if value != "" {
+			switch m.reviewEditField {
+			case "description":
+				d.Description = value
+			case "category":
+				d.Category = value
+			}
+		}
+		m.reviewEditField = ""
+	case tea.KeyTab:
+		if m.reviewEditField == "category" {
+			m.reviewInput = completeCategory(m.categorizer.GetAllCategories(), m.reviewInput)
+		}
+	default:
+		m.reviewInput = editInput(m.reviewInput, msg)
+	}
+	return m, nil
+}
+
+func (m model) viewReviewState() string {
+	title := titleStyle.Render("🔍 Review Import")
+
+	var content strings.Builder
+
+	if m.importUpdates != nil {
+		return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(m.renderImportProgress()))
+	}
+	if m.importErr != nil {
+		return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(m.renderImportError()))
+	}
+
+	if m.importSession == nil || m.importResult == nil {
+		content.WriteString("No import session to review.\n")
+	} else {
+		decisions := m.importSession.Decisions
+
+		accepted := 0
+		for _, d := range decisions {
+			if d.Action == importer.RowAccept {
+				accepted++
+			}
+		}
+
+		// Import summary
+		if len(m.importSession.Files) > 0 {
+			content.WriteString(fmt.Sprintf("Files: %d\n", len(m.importSession.Files)))
+			content.WriteString(renderFileSummaries(m.importSession.Files))
+		} else {
+			content.WriteString(fmt.Sprintf("File: %s\n", m.importSession.FileName))
+		}
+		content.WriteString(fmt.Sprintf("Format: %s\n", m.importSession.Source))
+		content.WriteString(fmt.Sprintf("Encoding: %s\n", m.importResult.Encoding))
+		content.WriteString(fmt.Sprintf("Total transactions: %d\n", len(m.importResult.Transactions)))
+		content.WriteString(fmt.Sprintf("Parse errors: %d\n", len(m.importResult.Errors)))
+		content.WriteString(fmt.Sprintf("Likely duplicates: %d\n", len(m.importResult.Duplicates)))
+		content.WriteString(fmt.Sprintf("Accepted: %d • Rejected: %d\n\n", accepted, len(decisions)-accepted))
+
+		if m.showImportErrors {
+			content.WriteString(m.renderImportErrors())
+			content.WriteString(fmt.Sprintf("\nStatus: %s\n", m.importStatus))
+			content.WriteString(helpStyle.Render(`
+↑↓/j/k: Navigate errors • Enter/e: Fix the row and parse it again
+w: Export rejected rows to CSV • x/esc: Back to the rows
+`))
+			return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()))
+		}
+
+		// Current page of rows
+		pageStart := (m.selectedPreview / reviewPageSize) * reviewPageSize
+		pageEnd := min(pageStart+reviewPageSize, len(decisions))
+		if len(decisions) > 0 {
+			content.WriteString(fmt.Sprintf("Rows %d-%d of %d:\n\n", pageStart+1, pageEnd, len(decisions)))
+		}
+
+		for i := pageStart; i < pageEnd; i++ {
+			d := decisions[i]
+			t := m.importResult.Transactions[d.Row]
+
+			cursor := " "
+			if i == m.selectedPreview {
+				cursor = ">"
+			}
+
+			status := positiveStyle.Render("✓")
+			if d.Action == importer.RowReject {
+				status = negativeStyle.Render("✗")
+			}
+
+			amountStr := fmt.Sprintf("$%.2f", t.Amount)
+			if t.Type == budget.Expense {
+				amountStr = negativeStyle.Render(amountStr)
+			} else {
+				amountStr = positiveStyle.Render(amountStr)
+			}
+
+			confidenceBar := getConfidenceBar(d.Confidence)
+
+			content.WriteString(fmt.Sprintf("%s %s %s %-20s %s %s\n",
+				cursor,
+				status,
+				t.Date.Format("Jan 02"),
+				Truncate(d.Description, 20, "…"),
+				amountStr,
+				confidenceBar))
+
+			categoryLine := fmt.Sprintf("    %s (%.0f%% confident)", d.Category, d.Confidence*100)
+			if d.Edited() {
+				categoryLine = fmt.Sprintf("    %s (suggested %s)", d.Category, d.SuggestedCategory)
+			}
+			if d.IsTransfer {
+				categoryLine += " [transfer]"
+			}
+			if len(t.Tags) > 0 {
+				categoryLine += helpStyle.Render(" #" + strings.Join(t.Tags, " #"))
+			}
+			if file := m.importSession.FileOf(d.Row); file != nil {
+				categoryLine += helpStyle.Render(" • " + filepath.Base(file.Path))
+			}
+			content.WriteString(categoryLine + "\n")
+
+			if d.Duplicate {
+				match, _ := m.importResult.DuplicateOf(d.Row)
+				content.WriteString(neutralStyle.Render(fmt.Sprintf("    ⚠ Likely duplicate: %s", match.Reason)) + "\n")
+			}
+		}
+
+		// Inline editor
+		if m.reviewEditField != "" {
+			label := "Description"
+			if m.reviewEditField == "category" {
+				label = "Category (Tab to complete)"
+			}
+			content.WriteString(fmt.Sprintf("\n%s: %s█\n", label, m.reviewInput))
+		}
+
+		content.WriteString(fmt.Sprintf("\nStatus: %s\n", m.importStatus))
+
+		// Details toggle
+		if m.showImportDetails {
+			content.WriteString("\n--- Import Details ---\n")
+			if len(m.importResult.Errors) > 0 {
+				content.WriteString("Parse errors:\n")
+				for _, message := range m.importResult.ErrorMessages() {
+					content.WriteString(fmt.Sprintf("  - %s\n", message))
+				}
+			} else {
+				content.WriteString("No parse errors detected.\n")
+			}
+		}
+	}
+
+	// Instructions
+	instructions := `
+Navigation:
+↑↓/j/k: Navigate rows • PgUp/PgDn: Page • g/G: First/last row
+a/r/space: Accept/reject/toggle row
+e: Edit description • o: Override category • t: Mark as transfer
+A: Accept all above 80% confidence • R: Reject all below 80%
+c: Confirm and import accepted rows
+d: Toggle details • x: Fix rows that failed • w: Export rejected rows
+q/esc: Cancel and return to dashboard
+`
+
+	content.WriteString(helpStyle.Render(instructions))
+
+	panel := borderStyle.Render(content.String())
+
+	return lipgloss.JoinVertical(lipgloss.Top, title, panel)
+}
+
+// renderImportErrors lists the rows that couldn't be imported, with the
+// selected one's raw fields and the field at fault highlighted
+func (m model) renderImportErrors() string {
+	var content strings.Builder
+	errs := m.importResult.Errors
+	content.WriteString(fmt.Sprintf("Rows that couldn't be imported: %d\n\n", len(errs)))
+
+	pageStart := (m.selectedError / reviewPageSize) * reviewPageSize
+	pageEnd := min(pageStart+reviewPageSize, len(errs))
+	for i := pageStart; i < pageEnd; i++ {
+		e := errs[i]
+		cursor := " "
+		if i == m.selectedError {
+			cursor = ">"
+		}
+
+		location := fmt.Sprintf("Row %d", e.Row)
+		if e.File != "" {
+			location = filepath.Base(e.File) + " " + location
+		}
+		if column := m.importResult.FormatOf(e).ColumnName(e.Column); column != "" {
+			location += ", " + column
+		}
+		content.WriteString(fmt.Sprintf("%s %s: %s\n", cursor, location, negativeStyle.Render(e.Message)))
+		if i != m.selectedError {
+			continue
+		}
+
+		if e.Fix != "" {
+			content.WriteString(neutralStyle.Render("    Fix: "+e.Fix) + "\n")
+		}
+		if len(e.Record) > 0 {
+			fields := make([]string, len(e.Record))
+			for j, field := range e.Record {
+				fields[j] = field
+				if j == e.Column {
+					fields[j] = negativeStyle.Render(field)
+				}
+			}
+			content.WriteString(helpStyle.Render("    Row: ") + strings.Join(fields, helpStyle.Render(" | ")) + "\n")
+		}
+	}
+
+	if m.reviewEditField == "error" {
+		label := "Fix line"
+		if m.errorEditColumn != importer.NoColumn {
+			label = "Fix " + m.importResult.FormatOf(errs[m.selectedError]).ColumnName(m.errorEditColumn)
+		}
+		content.WriteString(fmt.Sprintf("\n%s: %s█\n", label, m.reviewInput))
+	}
+	return content.String()
+}
+
+func getConfidenceBar(confidence float64) string {
+	width := 10
+	filled := int(confidence * float64(width))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+
+	var style lipgloss.Style
+	if confidence >= 0.8 {
+		style = positiveStyle
+	} else if confidence >= 0.5 {
+		style = neutralStyle
+	} else {
+		style = negativeStyle
+	}
+
+	return style.Render(bar)
+}
@@ -0,0 +1,368 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/pkg/categorizer"
+)
+
+// ruleFieldLabels name the rule editor's fields, in order
+var ruleFieldLabels = [ruleFieldCount]string{"Category", "Pattern", "Keywords", "Priority", "Min amount", "Max amount", "Type"}
+
+// editRule fills the rule editor with rule
+func (m *model) editRule(index int, rule categorizer.CategorizationRule) {
+	m.ruleEditing = true
+	m.ruleEditIndex = index
+	m.ruleField = 0
+	m.ruleInputs = [ruleFieldCount]string{
+		rule.Category,
+		rule.Pattern,
+		strings.Join(rule.Keywords, ", "),
+		strconv.Itoa(rule.Priority),
+		formatBound(rule.MinAmount),
+		formatBound(rule.MaxAmount),
+		string(rule.TransactionType),
+	}
+}
+
+func formatBound(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// ruleFromInputs reads the rule being edited. Fields that don't parse are
+// reported before the rule itself is validated.
+func (m model) ruleFromInputs() (categorizer.CategorizationRule, error) {
+	rule := categorizer.CategorizationRule{
+		Category:        strings.TrimSpace(m.ruleInputs[0]),
+		Pattern:         strings.TrimSpace(m.ruleInputs[1]),
+		TransactionType: budget.TransactionType(strings.ToLower(strings.TrimSpace(m.ruleInputs[6]))),
+		IsActive:        true,
+	}
+	if m.ruleEditIndex >= 0 {
+		// Conditions and actions are only edited in the rules file
+		existing := m.categorizer.Rules()[m.ruleEditIndex]
+		rule.IsActive = existing.IsActive
+		rule.Conditions = existing.Conditions
+		rule.Actions = existing.Actions
+		rule.Pack = existing.Pack
+	}
+	for _, keyword := range strings.Split(m.ruleInputs[2], ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			rule.Keywords = append(rule.Keywords, keyword)
+		}
+	}
+
+	priority, err := strconv.Atoi(strings.TrimSpace(m.ruleInputs[3]))
+	if err != nil {
+		return rule, errors.New("priority must be a whole number")
+	}
+	rule.Priority = priority
+	for i, bound := range []*float64{&rule.MinAmount, &rule.MaxAmount} {
+		value := strings.TrimSpace(m.ruleInputs[4+i])
+		if value == "" {
+			continue
+		}
+		if *bound, err = strconv.ParseFloat(value, 64); err != nil {
+			return rule, fmt.Errorf("%s must be a number", strings.ToLower(ruleFieldLabels[4+i]))
+		}
+	}
+	return rule, rule.Validate()
+}
+
+func (m model) updateRules(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.ruleEditing {
+		return m.updateRuleEditor(msg)
+	}
+	rules := m.categorizer.Rules()
+
+	if m.ruleConfirmDelete {
+		if msg.String() == "y" {
+			if err := m.categorizer.DeleteRule(m.ruleCursor); err != nil {
+				m.ruleStatus = err.Error()
+			} else {
+				m.ruleStatus = "rule deleted"
+				m.ruleCursor = max(min(m.ruleCursor, len(rules)-2), 0)
+				m.ruleConfirmDelete = false
+				return m, m.lintRules()
+			}
+		}
+		m.ruleConfirmDelete = false
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+	case "up", "k":
+		if m.ruleCursor > 0 {
+			m.ruleCursor--
+		}
+	case "down", "j":
+		if m.ruleCursor < len(rules)-1 {
+			m.ruleCursor++
+		}
+	case "n":
+		m.editRule(-1, categorizer.CategorizationRule{Priority: 80})
+	case "e", "enter":
+		if m.ruleCursor < len(rules) {
+			m.editRule(m.ruleCursor, rules[m.ruleCursor])
+		}
+	case " ":
+		if err := m.categorizer.ToggleRule(m.ruleCursor); err != nil {
+			m.ruleStatus = err.Error()
+		}
+		return m, m.lintRules()
+	case "K", "J":
+		delta := 1
+		if msg.String() == "K" {
+			delta = -1
+		}
+		cursor, err := m.categorizer.MoveRule(m.ruleCursor, delta)
+		if err != nil {
+			m.ruleStatus = err.Error()
+			break
+		}
+		m.ruleCursor = cursor
+		m.ruleStatus = fmt.Sprintf("priority now %d", m.categorizer.Rules()[cursor].Priority)
+		return m, m.lintRules()
+	case "d":
+		if m.ruleCursor < len(rules) {
+			m.ruleConfirmDelete = true
+		}
+	case "L":
+		// Jump to the next rule with a problem
+		for _, finding := range m.ruleLint {
+			if finding.Rule > m.ruleCursor {
+				m.ruleCursor = finding.Rule
+				return m, nil
+			}
+		}
+		if len(m.ruleLint) > 0 {
+			m.ruleCursor = m.ruleLint[0].Rule
+		}
+	}
+	return m, nil
+}
+
+// ruleLintMsg carries the findings of a lint run back from the background
+type ruleLintMsg struct {
+	run      int
+	findings []categorizer.LintFinding
+}
+
+// lintRules checks the rules against the budget's transactions again in
+// the background, as it takes a while with a long history
+func (m *model) lintRules() tea.Cmd {
+	m.ruleLintRun++
+	m.ruleLinting = true
+	run, c := m.ruleLintRun, m.categorizer
+	transactions := slices.Clone(m.budget.Transactions)
+	return func() tea.Msg {
+		return ruleLintMsg{run: run, findings: c.LintRules(transactions)}
+	}
+}
+
+// ruleFindings returns the lint findings for the rule at index i
+func (m model) ruleFindings(i int) []categorizer.LintFinding {
+	var findings []categorizer.LintFinding
+	for _, finding := range m.ruleLint {
+		if finding.Rule == i {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// formatFinding describes a lint finding on one line
+func formatFinding(finding categorizer.LintFinding) string {
+	line := fmt.Sprintf("%s: %s", finding.Kind, finding.Message)
+	if len(finding.Examples) > 0 {
+		line += fmt.Sprintf(", e.g. %q", finding.Examples[0])
+	}
+	return line
+}
+
+// formatRuleStats describes how often a rule's suggestions were kept and
+// the confidence that has earned each kind of match, like "12 matches • 3
+// overridden • 75% precise • pattern 90% → 80%"
+func formatRuleStats(report categorizer.RuleReport) string {
+	total := report.Stats.Total()
+	matches := "matches"
+	if total.Matches == 1 {
+		matches = "match"
+	}
+	parts := []string{fmt.Sprintf("%d %s", total.Matches, matches)}
+	if total.Matches > 0 {
+		parts = append(parts, fmt.Sprintf("%d overridden", total.Overrides), fmt.Sprintf("%.0f%% precise", total.Precision()*100))
+	}
+	parts = append(parts, FormatCalibration(report))
+	return strings.Join(parts, " • ")
+}
+
+// FormatCalibration lists the confidence each kind of match starts with
+// and, where reviews have moved it, what it has become
+func FormatCalibration(report categorizer.RuleReport) string {
+	var kinds []string
+	for matchedBy, confidence := range report.Confidence {
+		kind := fmt.Sprintf("%s %.0f%%", matchedBy, report.DefaultConfidence[matchedBy]*100)
+		if math.Round(confidence*100) != math.Round(report.DefaultConfidence[matchedBy]*100) {
+			kind += fmt.Sprintf(" → %.0f%%", confidence*100)
+		}
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, ", ")
+}
+
+// updateRuleEditor handles typing in the rule editor
+func (m model) updateRuleEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.ruleEditing = false
+	case tea.KeyTab, tea.KeyDown:
+		m.ruleField = (m.ruleField + 1) % ruleFieldCount
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.ruleField = (m.ruleField + ruleFieldCount - 1) % ruleFieldCount
+	case tea.KeyEnter:
+		rule, err := m.ruleFromInputs()
+		if err != nil {
+			m.ruleStatus = err.Error()
+			break
+		}
+		cursor, err := m.categorizer.SaveRule(m.ruleEditIndex, rule)
+		if err != nil {
+			m.ruleStatus = err.Error()
+			break
+		}
+		m.ruleEditing = false
+		m.ruleCursor = cursor
+		m.ruleStatus = "rule saved"
+		return m, m.lintRules()
+	case tea.KeyCtrlN:
+		if m.ruleField == 0 {
+			m.ruleInputs[0] = completeCategory(m.categorizer.GetAllCategories(), m.ruleInputs[0])
+		}
+	default:
+		m.ruleInputs[m.ruleField] = editInput(m.ruleInputs[m.ruleField], msg)
+	}
+	return m, nil
+}
+
+func (m model) viewRules() string {
+	title := titleStyle.Render("📐 Categorization Rules")
+	var content strings.Builder
+
+	if m.ruleEditing {
+		for i, label := range ruleFieldLabels {
+			prefix := " "
+			value := m.ruleInputs[i]
+			if i == m.ruleField {
+				prefix = ">"
+				value += "█"
+			}
+			content.WriteString(fmt.Sprintf("%s %-11s %s\n", prefix, label+":", value))
+
+			// Check the pattern as it is typed
+			if i == 1 && m.ruleInputs[1] != "" {
+				if err := categorizer.ValidatePattern(m.ruleInputs[1]); err != nil {
+					content.WriteString(negativeStyle.Render("              ✗ "+err.Error()) + "\n")
+				} else {
+					rule := categorizer.CategorizationRule{Pattern: m.ruleInputs[1], IsActive: true}
+					matches := len(m.categorizer.RuleMatches(rule, m.budget.Transactions))
+					content.WriteString(positiveStyle.Render(fmt.Sprintf("              ✓ matches %d transactions", matches)) + "\n")
+				}
+			}
+		}
+		content.WriteString(helpStyle.Render("\n  Keywords are comma-separated. Type is income, expense or empty for both.") + "\n")
+		content.WriteString(fmt.Sprintf("\n%s\n", m.ruleStatus))
+
+		nav := helpStyle.Render("Tab/↑↓: Field • Ctrl+N: Complete category • Enter: Save • esc: Cancel")
+		return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+	}
+
+	rules := m.categorizer.Rules()
+	start := 0
+	if m.ruleCursor >= reviewPageSize {
+		start = m.ruleCursor - reviewPageSize + 1
+	}
+	end := min(start+reviewPageSize, len(rules))
+	for i := start; i < end; i++ {
+		rule := rules[i]
+		cursor := " "
+		if i == m.ruleCursor {
+			cursor = ">"
+		}
+		active := positiveStyle.Render("✓")
+		if !rule.IsActive {
+			active = negativeStyle.Render("✗")
+		}
+		kind := "custom"
+		switch {
+		case rule.Pack != "":
+			kind = "pack: " + rule.Pack
+		case categorizer.IsDefaultRule(rule):
+			kind = "default"
+		}
+		if len(m.ruleFindings(i)) > 0 {
+			kind += neutralStyle.Render(" ⚠")
+		}
+		pattern := rule.Pattern
+		if len(pattern) > 24 {
+			pattern = pattern[:23] + "…"
+		}
+		content.WriteString(fmt.Sprintf("%s %s %3d %-18s %-24s %s\n",
+			cursor, active, rule.Priority, rule.Category, pattern, helpStyle.Render(kind)))
+
+		var details []string
+		if len(rule.Keywords) > 0 {
+			details = append(details, "keywords: "+strings.Join(rule.Keywords, ", "))
+		}
+		if rule.MinAmount > 0 || rule.MaxAmount > 0 {
+			details = append(details, fmt.Sprintf("amount: %s-%s", formatBound(rule.MinAmount), formatBound(rule.MaxAmount)))
+		}
+		if rule.TransactionType != "" {
+			details = append(details, string(rule.TransactionType)+" only")
+		}
+		if rule.Conditions != nil {
+			details = append(details, "when "+rule.Conditions.String())
+		}
+		if rule.Actions != nil {
+			details = append(details, "sets "+rule.Actions.String())
+		}
+		if len(details) > 0 {
+			content.WriteString(helpStyle.Render("        "+strings.Join(details, " • ")) + "\n")
+		}
+	}
+	problems := fmt.Sprintf("%d problems found", len(m.ruleLint))
+	if m.ruleLinting {
+		problems = "checking for problems…"
+	}
+	content.WriteString(helpStyle.Render(fmt.Sprintf("\n  %d rules • higher priority is tried first • %s", len(rules), problems)) + "\n")
+	if m.ruleCursor < len(rules) {
+		content.WriteString(helpStyle.Render("  "+formatRuleStats(m.categorizer.ReportRule(rules[m.ruleCursor]))) + "\n")
+	}
+	for _, finding := range m.ruleFindings(m.ruleCursor) {
+		content.WriteString(neutralStyle.Render("  ⚠ "+formatFinding(finding)) + "\n")
+	}
+
+	if m.ruleConfirmDelete {
+		content.WriteString(neutralStyle.Render("\nDelete this rule? (y/n)") + "\n")
+	}
+	content.WriteString(fmt.Sprintf("\n%s\n", m.ruleStatus))
+
+	nav := helpStyle.Render("↑↓/j/k: Navigate • n: New • e/Enter: Edit • Space: On/off • K/J: Move up/down • d: Delete • L: Next problem • esc: Back")
+	return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+}
@@ -0,0 +1,194 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// openRuleSuggestions proposes rules for the uncategorized transactions
+func (m *model) openRuleSuggestions() {
+	m.suggestions = m.categorizer.SuggestRules(m.budget.Transactions)
+	m.suggestionCursor = 0
+	m.suggestionEditing = false
+	m.pendingRecategorize = nil
+	m.suggestionStatus = fmt.Sprintf("%d rules suggested", len(m.suggestions))
+}
+
+func (m model) updateRuleSuggestions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.suggestionEditing {
+		return m.updateSuggestionCategory(msg)
+	}
+
+	if m.pendingRecategorize != nil {
+		switch msg.String() {
+		case "y":
+			count := m.recategorize(m.pendingRecategorize, m.pendingCategory)
+			m.budget.Save()
+			m.suggestionStatus = fmt.Sprintf("recategorized %d transactions as %s", count, m.pendingCategory)
+			m.pendingRecategorize = nil
+		case "n", "esc":
+			m.suggestionStatus = "rule saved; existing transactions left as they are"
+			m.pendingRecategorize = nil
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+	case "up", "k":
+		if m.suggestionCursor > 0 {
+			m.suggestionCursor--
+		}
+	case "down", "j":
+		if m.suggestionCursor < len(m.suggestions)-1 {
+			m.suggestionCursor++
+		}
+	case "e":
+		if m.suggestionCursor < len(m.suggestions) {
+			m.suggestionEditing = true
+			m.suggestionInput = m.suggestions[m.suggestionCursor].Rule.Category
+		}
+	case "a", "enter":
+		if m.suggestionCursor >= len(m.suggestions) {
+			break
+		}
+		rule := m.suggestions[m.suggestionCursor].Rule
+		if rule.Category == "" {
+			m.suggestionEditing = true
+			m.suggestionInput = ""
+			m.suggestionStatus = "choose a category for the rule"
+			break
+		}
+		if err := m.categorizer.AddCustomRule(rule); err != nil {
+			m.suggestionStatus = fmt.Sprintf("couldn't save rule: %v", err)
+			break
+		}
+		m.dismissSuggestion()
+
+		// Categories chosen by hand are left as they are
+		var matches []int
+		manual := 0
+		for _, i := range m.categorizer.RuleMatches(rule, m.budget.Transactions) {
+			switch t := m.budget.Transactions[i]; {
+			case t.Category == rule.Category || t.IsLocked():
+			case t.CategorySetByUser():
+				manual++
+			default:
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 0 {
+			m.suggestionStatus = "rule saved"
+			break
+		}
+		m.pendingRecategorize = matches
+		m.pendingCategory = rule.Category
+		m.pendingManual = manual
+	case "x":
+		m.dismissSuggestion()
+	case "R":
+		m.openRuleSuggestions()
+	}
+	return m, nil
+}
+
+// updateSuggestionCategory handles typing the category of a suggested rule
+func (m model) updateSuggestionCategory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.suggestionEditing = false
+	case tea.KeyEnter:
+		m.suggestionEditing = false
+		if value := strings.TrimSpace(m.suggestionInput); value != "" {
+			m.suggestions[m.suggestionCursor].Rule.Category = value
+		}
+	case tea.KeyTab:
+		m.suggestionInput = completeCategory(m.categorizer.GetAllCategories(), m.suggestionInput)
+	default:
+		m.suggestionInput = editInput(m.suggestionInput, msg)
+	}
+	return m, nil
+}
+
+func (m *model) dismissSuggestion() {
+	m.suggestions = append(m.suggestions[:m.suggestionCursor], m.suggestions[m.suggestionCursor+1:]...)
+	m.suggestionCursor = max(min(m.suggestionCursor, len(m.suggestions)-1), 0)
+}
+
+// recategorize gives the transactions at indexes category, skipping
+// reconciled ones, and learns from the change. It returns how many changed.
+func (m *model) recategorize(indexes []int, category string) int {
+	count := 0
+	for _, i := range indexes {
+		before := m.budget.Transactions[i]
+		err := m.budget.UpdateTransaction(before.ID, func(t *budget.Transaction) {
+			t.Category = category
+			t.Splits = nil
+		})
+		if err != nil {
+			continue
+		}
+		m.categorizer.Recategorize(before, m.budget.Transactions[i])
+		count++
+	}
+	return count
+}
+
+func (m model) viewRuleSuggestions() string {
+	title := titleStyle.Render("💡 Suggested Rules")
+
+	var content strings.Builder
+	if len(m.suggestions) == 0 {
+		content.WriteString("No suggestions: uncategorized transactions don't share a merchant.\n")
+	}
+
+	start := 0
+	if m.suggestionCursor >= reviewPageSize {
+		start = m.suggestionCursor - reviewPageSize + 1
+	}
+	end := min(start+reviewPageSize, len(m.suggestions))
+	for i := start; i < end; i++ {
+		suggestion := m.suggestions[i]
+		cursor := " "
+		if i == m.suggestionCursor {
+			cursor = ">"
+		}
+
+		category := suggestion.Rule.Category
+		if category == "" {
+			category = neutralStyle.Render("(choose a category)")
+		}
+		content.WriteString(fmt.Sprintf("%s %-24s %3d transactions → %s\n",
+			cursor, strings.Join(suggestion.Rule.Keywords, ", "), len(suggestion.Matches), category))
+		if i == m.suggestionCursor {
+			content.WriteString(helpStyle.Render("    pattern: "+suggestion.Rule.Pattern) + "\n")
+			for _, example := range suggestion.Examples {
+				content.WriteString(helpStyle.Render("    e.g. "+example) + "\n")
+			}
+		}
+	}
+	if end < len(m.suggestions) {
+		content.WriteString(helpStyle.Render(fmt.Sprintf("  ... %d more", len(m.suggestions)-end)) + "\n")
+	}
+
+	if m.suggestionEditing {
+		content.WriteString(fmt.Sprintf("\nCategory (Tab to complete): %s█\n", m.suggestionInput))
+	}
+	if m.pendingRecategorize != nil {
+		content.WriteString(neutralStyle.Render(fmt.Sprintf("\nRecategorize %d matching transactions as %s? (y/n)",
+			len(m.pendingRecategorize), m.pendingCategory)) + "\n")
+		if m.pendingManual > 0 {
+			content.WriteString(helpStyle.Render(fmt.Sprintf("%d more match but keep the category you chose", m.pendingManual)) + "\n")
+		}
+	}
+	content.WriteString(fmt.Sprintf("\n%s\n", m.suggestionStatus))
+
+	nav := helpStyle.Render("↑↓/j/k: Navigate • a/Enter: Accept rule • e: Edit category • x: Dismiss • R: Refresh • esc: Back")
+	return lipgloss.JoinVertical(lipgloss.Top, title, borderStyle.Render(content.String()), nav)
+}
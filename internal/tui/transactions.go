@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func (m *model) resetForm() {
+	m.amountInput = ""
+	m.descriptionInput = ""
+	m.categoryInput = ""
+	m.activeField = 0
+	m.formSubmitted = false
+}
+
+func (m model) updateAddTransactionForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+	case "tab":
+		m.activeField = (m.activeField + 1) % 3
+	case "shift+tab":
+		m.activeField = (m.activeField - 1 + 3) % 3
+	case "enter":
+		if m.amountInput != "" && m.descriptionInput != "" && m.categoryInput != "" {
+			var amount float64
+			fmt.Sscanf(m.amountInput, "%f", &amount)
+
+			tType := budget.Income
+			if m.state == addExpenseState {
+				tType = budget.Expense
+			}
+
+			m.budget.AddTransaction(amount, m.descriptionInput, m.categoryInput, tType)
+			m.budget.Save()
+			m.categorizer.Learn(m.budget.Transactions[len(m.budget.Transactions)-1])
+			m.formSubmitted = true
+			m.state = dashboardState
+		}
+	case "backspace":
+		switch m.activeField {
+		case 0:
+			if len(m.amountInput) > 0 {
+				m.amountInput = m.amountInput[:len(m.amountInput)-1]
+			}
+		case 1:
+			if len(m.descriptionInput) > 0 {
+				m.descriptionInput = m.descriptionInput[:len(m.descriptionInput)-1]
+			}
+		case 2:
+			if len(m.categoryInput) > 0 {
+				m.categoryInput = m.categoryInput[:len(m.categoryInput)-1]
+			}
+		}
+	default:
+		if len(msg.String()) == 1 {
+			switch m.activeField {
+			case 0:
+				m.amountInput += msg.String()
+			case 1:
+				m.descriptionInput += msg.String()
+			case 2:
+				m.categoryInput += msg.String()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m model) viewAddTransactionForm() string {
+	title := "Add Income"
+	if m.state == addExpenseState {
+		title = "Add Expense"
+	}
+
+	s := fmt.Sprintf("➕ %s\n\n", title)
+
+	fields := []struct {
+		label  string
+		value  string
+		active bool
+	}{
+		{"Amount", m.amountInput, m.activeField == 0},
+		{"Description", m.descriptionInput, m.activeField == 1},
+		{"Category", m.categoryInput, m.activeField == 2},
+	}
+
+	for _, field := range fields {
+		prefix := " "
+		if field.active {
+			prefix = ">"
+		}
+		s += fmt.Sprintf("%s %s: %s\n", prefix, field.label, field.value)
+	}
+
+	s += "\nTab: switch fields • Enter: save • q/esc: return to dashboard"
+	return s
+}
+
+func (m model) updateViewTransactions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.transactionEditing {
+		return m.updateTransactionCategory(msg)
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.state = dashboardState
+		m.transactionStatus = ""
+	case "up", "k":
+		if m.selectedTransaction > 0 {
+			m.selectedTransaction--
+		}
+	case "down", "j":
+		if m.selectedTransaction < len(m.budget.Transactions)-1 {
+			m.selectedTransaction++
+		}
+	case "x":
+		if m.selectedTransaction < len(m.budget.Transactions) {
+			t := m.budget.Transactions[m.selectedTransaction]
+			if t.OriginalDescription != "" {
+				t.Description = t.OriginalDescription
+			}
+			m.openExplain(t)
+		}
+	case "o":
+		if m.selectedTransaction < len(m.budget.Transactions) {
+			if m.budget.Transactions[m.selectedTransaction].IsLocked() {
+				m.transactionStatus = budget.ErrReconciled.Error()
+				break
+			}
+			m.transactionEditing = true
+			m.transactionInput = ""
+		}
+	case "t":
+		if m.selectedTransaction < len(m.budget.Transactions) {
+			err := m.budget.UpdateTransaction(m.budget.Transactions[m.selectedTransaction].ID, func(t *budget.Transaction) {
+				t.IsTransfer = !t.IsTransfer
+			})
+			if err != nil {
+				m.transactionStatus = err.Error()
+				break
+			}
+			m.budget.Save()
+			m.transactionStatus = "no longer a transfer"
+			if m.budget.Transactions[m.selectedTransaction].IsTransfer {
+				m.transactionStatus = "marked as a transfer"
+			}
+		}
+	}
+	return m, nil
+}
+
+// updateTransactionCategory handles typing a transaction's new category.
+// The change is learned so similar transactions are categorized the same
+// way from then on.
+func (m model) updateTransactionCategory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.transactionEditing = false
+	case tea.KeyEnter:
+		m.transactionEditing = false
+		value := strings.TrimSpace(m.transactionInput)
+		if value == "" {
+			break
+		}
+
+		before := m.budget.Transactions[m.selectedTransaction]
+		err := m.budget.UpdateTransaction(before.ID, func(t *budget.Transaction) {
+			t.Category = value
+			t.ManualCategory = true
+			t.Splits = nil
+		})
+		if err != nil {
+			m.transactionStatus = err.Error()
+			break
+		}
+		m.categorizer.Recategorize(before, m.budget.Transactions[m.selectedTransaction])
+		m.categorizer.RecordOverride(before)
+		m.budget.Save()
+		m.transactionStatus = fmt.Sprintf("moved %q to %s", before.Description, value)
+	case tea.KeyTab:
+		m.transactionInput = completeCategory(m.categorizer.GetAllCategories(), m.transactionInput)
+	default:
+		m.transactionInput = editInput(m.transactionInput, msg)
+	}
+	return m, nil
+}
+
+func (m model) viewTransactions() string {
+	s := "📝 Recent Transactions\n\n"
+
+	if len(m.budget.Transactions) == 0 {
+		s += "No transactions yet.\n"
+	} else {
+		for i, t := range m.budget.Transactions {
+			cursor := " "
+			if i == m.selectedTransaction {
+				cursor = ">"
+			}
+
+			symbol := "📈"
+			if t.Type == budget.Expense {
+				symbol = "📉"
+			}
+
+			status := ""
+			switch t.Status {
+			case budget.Cleared:
+				status = " ✓"
+			case budget.Reconciled:
+				status = " 🔒"
+			}
+
+			category := t.Category
+			if len(t.Splits) > 0 {
+				shares := make([]string, len(t.Splits))
+				for j, split := range t.Splits {
+					shares[j] = fmt.Sprintf("%s $%.2f", split.Category, split.Amount)
+				}
+				category = strings.Join(shares, ", ")
+			}
+			payee := ""
+			if t.Payee != "" {
+				payee = " → " + t.Payee
+			}
+			if t.IsTransfer {
+				status += " ⇄"
+			}
+
+			s += fmt.Sprintf("%s %s $%.2f - %s%s (%s)%s\n",
+				cursor, symbol, t.Amount, t.Description, payee, category, status)
+		}
+	}
+
+	if m.transactionEditing {
+		s += fmt.Sprintf("\nCategory (Tab to complete): %s█\n", m.transactionInput)
+	}
+	if m.transactionStatus != "" {
+		s += "\n" + m.transactionStatus + "\n"
+	}
+
+	s += "\n↑↓/j/k: navigate • o: change category • t: mark as transfer • x: explain category • q/esc: return to dashboard"
+	return s
+}
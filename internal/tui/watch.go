@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+	"github.com/Elwdipath/budget_tui/internal/config"
+	"github.com/Elwdipath/budget_tui/internal/importer"
+)
+
+type watchTickMsg struct{}
+
+// preparedImport is a watched file after parsing and categorization
+type preparedImport struct {
+	hash    string
+	session *importer.ImportSession
+	result  *importer.ImportResult
+	err     error
+}
+
+type watchScanMsg struct {
+	imports []preparedImport
+	err     error
+}
+
+// watchTick schedules the next check of the watch folder
+func (m model) watchTick() tea.Cmd {
+	if m.config.WatchDir == "" {
+		return nil
+	}
+	return tea.Tick(m.config.GetWatchInterval(), func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+// scanWatchFolder parses new files in the watch folder in the background.
+// The results are applied to the budget in Update.
+func (m model) scanWatchFolder() tea.Cmd {
+	if m.config.WatchDir == "" {
+		return nil
+	}
+	dir := config.ExpandHome(m.config.WatchDir)
+
+	seen := make(map[string]bool)
+	for _, session := range m.importHistory.Sessions {
+		seen[session.FileHash] = true
+		for _, file := range session.Files {
+			seen[file.Hash] = true
+		}
+	}
+	for _, hash := range m.pendingQueue.SeenHashes {
+		seen[hash] = true
+	}
+
+	existing := make([]budget.Transaction, len(m.budget.Transactions))
+	copy(existing, m.budget.Transactions)
+	existing = append(existing, m.pendingQueue.Transactions()...)
+	c := m.categorizer
+	locale, _ := importer.LookupLocale(m.config.Locale)
+
+	return func() tea.Msg {
+		files, err := importer.ScanWatchFolder(dir, func(hash string) bool { return seen[hash] })
+		if err != nil {
+			return watchScanMsg{err: err}
+		}
+
+		var imports []preparedImport
+		for _, file := range files {
+			session, result, err := importer.PrepareImport(context.Background(), file.Path, nil, importer.EncodingAuto, locale, "", existing, c, nil)
+			imports = append(imports, preparedImport{hash: file.Hash, session: session, result: result, err: err})
+			if result != nil {
+				existing = append(existing, result.Transactions...)
+			}
+		}
+		return watchScanMsg{imports: imports}
+	}
+}
+
+// applyWatchScan imports fully confident files when auto-import is on and
+// queues everything else for review
+func (m *model) applyWatchScan(msg watchScanMsg) {
+	if msg.err != nil {
+		m.watchStatus = "watch folder error: " + msg.err.Error()
+		return
+	}
+	if len(msg.imports) == 0 {
+		return
+	}
+
+	autoImported := 0
+	for _, prepared := range msg.imports {
+		m.pendingQueue.MarkSeen(prepared.hash)
+
+		switch {
+		case prepared.err != nil:
+			prepared.session.Status = "error"
+			prepared.session.Errors = append(prepared.session.Errors, prepared.err.Error())
+			m.importHistory.AddSession(*prepared.session)
+		case m.config.AutoImport && prepared.session.FullyConfident(prepared.result, m.config.GetAutoImportConfidence()):
+			count := importer.ApplyImport(m.budget, prepared.session, prepared.result)
+			imported := m.budget.Transactions[len(m.budget.Transactions)-count:]
+			for _, t := range imported {
+				m.categorizer.Learn(t)
+			}
+			// Counted as kept, so changing one later counts against its rule
+			m.categorizer.RecordOutcomes(imported)
+			m.importHistory.AddSession(*prepared.session)
+			autoImported++
+		default:
+			prepared.session.Status = "pending"
+			m.pendingQueue.Add(*prepared.session, *prepared.result)
+		}
+	}
+
+	if autoImported > 0 {
+		m.budget.Save()
+	}
+	m.importHistory.Save()
+	m.pendingQueue.Save()
+	m.watchStatus = fmt.Sprintf("found %d new files, auto-imported %d", len(msg.imports), autoImported)
+}
+
+// openPendingImport loads the oldest queued import into the review screen
+func (m *model) openPendingImport() {
+	if len(m.pendingQueue.Items) == 0 {
+		return
+	}
+
+	m.resetImportState()
+	item := m.pendingQueue.Items[0]
+	session := item.Session
+	result := item.Result
+
+	session.Status = "reviewing"
+	m.importSession = &session
+	m.importResult = &result
+	m.importFilePath = session.FileName
+	m.importFormat = &result.Format
+	m.state = reviewState
+	m.importStatus = "ready for review"
+}
@@ -5,8 +5,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
 
 	"github.com/Elwdipath/budget_tui/internal/budget"
 )
@@ -20,60 +22,98 @@ type CategorizationRule struct {
 	Priority        int                    `json:"priority"`
 	IsActive        bool                   `json:"is_active"`
 	TransactionType budget.TransactionType `json:"transaction_type,omitempty"`
+	// Conditions must also hold for the rule to match. A rule with
+	// conditions but no pattern or keywords matches on them alone.
+	Conditions *Condition `json:"conditions,omitempty"`
+	// Actions are applied to the transactions the rule categorizes
+	Actions *RuleActions `json:"actions,omitempty"`
+	// Pack names the rule pack the rule was installed from
+	Pack string `json:"pack,omitempty"`
 }
 
 type CategoryConfig struct {
 	Rules []CategorizationRule `json:"rules"`
+	// RemovedDefaults holds the patterns of the default rules that were
+	// deleted or changed; changed ones are in Rules
+	RemovedDefaults []string `json:"removed_defaults,omitempty"`
+	// ReplaceDefaults was set by older versions once the rules had been
+	// managed in the app: Rules then holds every rule, defaults included
+	ReplaceDefaults bool `json:"replace_defaults,omitempty"`
+	// Packs are the rule packs installed; their rules are in Rules
+	Packs []PackInfo `json:"packs,omitempty"`
 }
 
 type Categorizer struct {
+	mu    sync.RWMutex
 	rules []CategorizationRule
+	packs []PackInfo
+	// index is rebuilt whenever the rules change
+	index *ruleIndex
+	// learned picks up the categories the user gives transactions
+	learned *Classifier
+	// stats keeps score of how often the user keeps each rule's suggestions
+	stats *ruleStatsStore
 }
 
 func NewCategorizer() *Categorizer {
-	categorizer := &Categorizer{}
+	categorizer := &Categorizer{learned: NewClassifier(), stats: loadRuleStats()}
 	categorizer.loadRules()
 	return categorizer
 }
 
 func (c *Categorizer) loadRules() {
 	// Load custom rules if they exist
-	customRules, err := c.loadCustomRules()
-	if err == nil {
-		c.rules = append(c.rules, customRules...)
+	config, err := c.loadCustomRules()
+	if err != nil {
+		config = CategoryConfig{}
 	}
 
-	// Add default rules
-	c.rules = append(c.rules, c.getDefaultRules()...)
-
-	// Sort by priority (higher priority first)
-	for i := 0; i < len(c.rules)-1; i++ {
-		for j := i + 1; j < len(c.rules); j++ {
-			if c.rules[j].Priority > c.rules[i].Priority {
-				c.rules[i], c.rules[j] = c.rules[j], c.rules[i]
+	// The default rules that haven't been removed or changed come first, so
+	// saved rules follow the defaults of the same priority
+	if !config.ReplaceDefaults {
+		for _, rule := range c.getDefaultRules() {
+			if !slices.Contains(config.RemovedDefaults, rule.Pattern) {
+				c.rules = append(c.rules, rule)
 			}
 		}
 	}
+	c.rules = append(c.rules, config.Rules...)
+	c.packs = config.Packs
+
+	sortRules(c.rules)
+	c.index = newRuleIndex(c.rules)
+}
+
+// sortRules puts higher priority rules first, keeping the order of rules
+// with the same priority
+func sortRules(rules []CategorizationRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+}
+
+func rulesPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".budget_tui_rules.json")
 }
 
-func (c *Categorizer) loadCustomRules() ([]CategorizationRule, error) {
-	filePath := filepath.Join(os.Getenv("HOME"), ".budget_tui_rules.json")
+func (c *Categorizer) loadCustomRules() (CategoryConfig, error) {
+	filePath := rulesPath()
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return []CategorizationRule{}, nil
+		return CategoryConfig{}, nil
 	}
 
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, err
+		return CategoryConfig{}, err
 	}
 
 	var config CategoryConfig
 	err = json.Unmarshal(data, &config)
 	if err != nil {
-		return nil, err
+		return CategoryConfig{}, err
 	}
 
-	return config.Rules, nil
+	return config, nil
 }
 
 func (c *Categorizer) getDefaultRules() []CategorizationRule {
@@ -249,6 +289,7 @@ func (c *Categorizer) getDefaultRules() []CategorizationRule {
 			Priority: 60,
 			IsActive: true,
 			Keywords: []string{"transfer", "xfer"},
+			Actions:  &RuleActions{Transfer: true},
 		},
 		{
 			Pattern:  ".*Payment.*",
@@ -260,96 +301,115 @@ func (c *Categorizer) getDefaultRules() []CategorizationRule {
 	}
 }
 
+// CategorizeTransaction suggests a category from the rules and what has been
+// learned from the user's own categories. When both agree the suggestion is
+// more confident; otherwise the more confident of the two wins.
 func (c *Categorizer) CategorizeTransaction(description string, amount float64, transType budget.TransactionType) (string, float64) {
-	description = strings.ToLower(strings.TrimSpace(description))
-	bestMatch := "Uncategorized"
-	bestConfidence := 0.0
-
-	for _, rule := range c.rules {
-		if !rule.IsActive {
-			continue
-		}
-
-		// Check transaction type if specified
-		if rule.TransactionType != "" && rule.TransactionType != transType {
-			continue
-		}
+	result := c.Categorize(budget.Transaction{Description: description, Amount: amount, Type: transType})
+	return result.Category, result.Confidence
+}
 
-		// Check amount constraints
-		if rule.MinAmount > 0 && amount < rule.MinAmount {
-			continue
-		}
-		if rule.MaxAmount > 0 && amount > rule.MaxAmount {
-			continue
-		}
+// Categorization is the category suggested for a transaction
+type Categorization struct {
+	Category   string
+	Confidence float64
+	// Rule identifies the winning rule in its stats and MatchedBy says how
+	// it matched; both are empty when the category was learned
+	Rule      string
+	MatchedBy string
+	// Actions are the winning rule's
+	Actions RuleActions
+}
 
-		var confidence float64
-		var matched bool
+// Apply sets t's category and records where it came from, making the
+// winning rule's changes
+func (result Categorization) Apply(t *budget.Transaction) {
+	t.Category = result.Category
+	t.CategorizedBy, t.MatchedBy = result.Rule, result.MatchedBy
+	result.Actions.Apply(t)
+}
 
-		// Try regex pattern first
-		if rule.Pattern != "" {
-			matched, _ = regexp.MatchString(strings.ToLower(rule.Pattern), description)
-			if matched {
-				confidence = 0.9
-			}
-		}
+// Categorize is CategorizeTransaction for a whole transaction, so rules can
+// test its account, date and source too. It also says which rule won and
+// returns its actions, when the category is the rule's.
+func (c *Categorizer) Categorize(t budget.Transaction) Categorization {
+	result := c.matchRules(newSubject(t))
+
+	learned, learnedConfidence := c.learned.Predict(t.Description, t.Amount, t.Type)
+	switch {
+	case learned == "":
+	case learned == result.Category:
+		result.Confidence = 1 - (1-result.Confidence)*(1-learnedConfidence)
+	case learnedConfidence > result.Confidence:
+		result = Categorization{Category: learned, Confidence: learnedConfidence}
+	}
+	return result
+}
 
-		// Try keywords if pattern didn't match
-		if !matched && len(rule.Keywords) > 0 {
-			for _, keyword := range rule.Keywords {
-				if strings.Contains(description, strings.ToLower(keyword)) {
-					matched = true
-					confidence = 0.8
-					break
-				}
-			}
-		}
+// Train learns from every categorized transaction, replacing what was
+// learned before
+func (c *Categorizer) Train(transactions []budget.Transaction) {
+	learned := NewClassifier()
+	for _, t := range transactions {
+		learned.Learn(t)
+	}
+	c.learned = learned
+}
 
-		if matched {
-			// Adjust confidence based on rule priority
-			confidence = confidence * (float64(rule.Priority) / 100.0)
+// Learn adds a newly categorized transaction to what has been learned
+func (c *Categorizer) Learn(t budget.Transaction) {
+	c.learned.Learn(t)
+}
 
-			// Add confidence for exact matches
-			if strings.Contains(description, "amazon") && rule.Category == "Shopping" {
-				confidence = math.Min(confidence+0.1, 1.0)
-			}
+// Recategorize updates what has been learned when a transaction's category
+// changes from before to after
+func (c *Categorizer) Recategorize(before, after budget.Transaction) {
+	c.learned.Forget(before)
+	c.learned.Learn(after)
+}
 
-			if confidence > bestConfidence {
-				bestMatch = rule.Category
-				bestConfidence = confidence
+func (c *Categorizer) matchRules(s subject) Categorization {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	best := Categorization{Category: "Uncategorized"}
+
+	for _, i := range c.index.candidates(s.description) {
+		rule := c.rules[i]
+		if evaluation := rule.evaluateCompiled(s, c.index.patterns[i]); evaluation.Matched {
+			evaluation = c.calibrate(evaluation, c.index.keys[i], s.description)
+			if evaluation.Confidence > best.Confidence {
+				best = Categorization{
+					Category:   rule.Category,
+					Confidence: evaluation.Confidence,
+					Rule:       c.index.keys[i],
+					MatchedBy:  evaluation.MatchedBy,
+				}
+				if rule.Actions != nil {
+					best.Actions = *rule.Actions
+				}
 			}
 		}
 	}
 
-	return bestMatch, math.Min(bestConfidence, 1.0)
+	best.Confidence = math.Min(best.Confidence, 1.0)
+	return best
 }
 
 func (c *Categorizer) AddCustomRule(rule CategorizationRule) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.rules = append(c.rules, rule)
+	sortRules(c.rules)
+	c.index = newRuleIndex(c.rules)
 	return c.saveCustomRules()
 }
 
 func (c *Categorizer) saveCustomRules() error {
-	filePath := filepath.Join(os.Getenv("HOME"), ".budget_tui_rules.json")
-
-	// Separate custom rules (non-default ones)
-	var customRules []CategorizationRule
-	defaultRules := c.getDefaultRules()
+	filePath := rulesPath()
 
-	for _, rule := range c.rules {
-		isDefault := false
-		for _, defaultRule := range defaultRules {
-			if rule.Pattern == defaultRule.Pattern && rule.Category == defaultRule.Category {
-				isDefault = true
-				break
-			}
-		}
-		if !isDefault {
-			customRules = append(customRules, rule)
-		}
-	}
-
-	config := CategoryConfig{Rules: customRules}
+	customRules, removed := c.customRules()
+	config := CategoryConfig{Rules: customRules, RemovedDefaults: removed, Packs: c.packs}
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
@@ -358,13 +418,56 @@ func (c *Categorizer) saveCustomRules() error {
 	return os.WriteFile(filePath, data, 0644)
 }
 
+// customRules returns what the rules file has to hold: the user's rules
+// and changed defaults, and the patterns of the defaults that are gone or
+// changed. An unchanged default is left out unless it is needed to keep
+// its place among the rules of its priority, as defaults are loaded before
+// the saved rules in their built-in order.
+func (c *Categorizer) customRules() ([]CategorizationRule, []string) {
+	builtIn := make(map[string]int)
+	for i, rule := range defaultRules {
+		builtIn[rule.Pattern] = i
+	}
+
+	// A default is left out while no saved rule of its priority comes
+	// before it and the defaults left out stay in their built-in order
+	var rules []CategorizationRule
+	savedBefore := make(map[int]bool)
+	lastDefault := make(map[int]int)
+	kept := make(map[string]bool)
+	for _, rule := range c.rules {
+		d, isDefault := builtIn[rule.Pattern]
+		last, hasLast := lastDefault[rule.Priority]
+		if isDefault && reflect.DeepEqual(rule, defaultRules[d]) && !savedBefore[rule.Priority] && (!hasLast || d > last) {
+			lastDefault[rule.Priority] = d
+			kept[rule.Pattern] = true
+			continue
+		}
+		rules = append(rules, rule)
+		savedBefore[rule.Priority] = true
+	}
+
+	var removed []string
+	for _, rule := range defaultRules {
+		if !kept[rule.Pattern] {
+			removed = append(removed, rule.Pattern)
+		}
+	}
+	return rules, removed
+}
+
 func (c *Categorizer) GetAllCategories() []string {
 	categories := make(map[string]bool)
 	categories["Uncategorized"] = true
 
+	c.mu.RLock()
 	for _, rule := range c.rules {
 		categories[rule.Category] = true
 	}
+	c.mu.RUnlock()
+	for _, category := range c.learned.Categories() {
+		categories[category] = true
+	}
 
 	var result []string
 	for category := range categories {
@@ -0,0 +1,207 @@
+package categorizer
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// Classifier is a naive Bayes model of how the user categorizes
+// transactions, learned from the words of their descriptions, the size of
+// the amount and whether it is income or an expense. It is trained on the
+// budget's categorized transactions and updated whenever a category changes.
+type Classifier struct {
+	mu sync.RWMutex
+	// docs counts the transactions learned per category
+	docs map[string]int
+	// features counts how often each feature was seen per category
+	features      map[string]map[string]int
+	featureTotals map[string]int
+	vocabulary    map[string]int
+	total         int
+}
+
+// minSupport is how many times a description word has to have been seen in
+// a category before its prediction is fully trusted
+const minSupport = 2
+
+func NewClassifier() *Classifier {
+	return &Classifier{
+		docs:          make(map[string]int),
+		features:      make(map[string]map[string]int),
+		featureTotals: make(map[string]int),
+		vocabulary:    make(map[string]int),
+	}
+}
+
+// learnable reports whether t's category is worth learning from
+func learnable(t budget.Transaction) bool {
+	return t.Category != "" && t.Category != "Uncategorized"
+}
+
+// Learn adds a categorized transaction to the model
+func (cl *Classifier) Learn(t budget.Transaction) {
+	if !learnable(t) {
+		return
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.update(t.Category, features(t.Description, t.Amount, t.Type), 1)
+}
+
+// Forget removes a transaction learned earlier, such as before its category
+// is changed
+func (cl *Classifier) Forget(t budget.Transaction) {
+	if !learnable(t) {
+		return
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.docs[t.Category] == 0 {
+		return
+	}
+	cl.update(t.Category, features(t.Description, t.Amount, t.Type), -1)
+}
+
+func (cl *Classifier) update(category string, features []string, delta int) {
+	cl.docs[category] += delta
+	cl.total += delta
+	if cl.features[category] == nil {
+		cl.features[category] = make(map[string]int)
+	}
+	for _, f := range features {
+		cl.features[category][f] += delta
+		cl.featureTotals[category] += delta
+		cl.vocabulary[f] += delta
+		if cl.vocabulary[f] <= 0 {
+			delete(cl.vocabulary, f)
+		}
+	}
+	if cl.docs[category] <= 0 {
+		delete(cl.docs, category)
+		delete(cl.features, category)
+		delete(cl.featureTotals, category)
+	}
+}
+
+// Categories lists the categories the model has learned
+func (cl *Classifier) Categories() []string {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	categories := make([]string, 0, len(cl.docs))
+	for category := range cl.docs {
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+// Predict returns the most likely category for a transaction and how sure
+// the model is, or "" when none of the description's words have been seen
+func (cl *Classifier) Predict(description string, amount float64, transType budget.TransactionType) (string, float64) {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+
+	if cl.total == 0 {
+		return "", 0
+	}
+	fs := features(description, amount, transType)
+
+	known := false
+	for _, f := range fs {
+		if isWord(f) && cl.vocabulary[f] > 0 {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", 0
+	}
+
+	// Log-likelihood of each category with add-one smoothing
+	vocabulary := float64(len(cl.vocabulary))
+	scores := make(map[string]float64, len(cl.docs))
+	best, bestScore := "", math.Inf(-1)
+	for category, docs := range cl.docs {
+		score := math.Log(float64(docs) / float64(cl.total))
+		denominator := float64(cl.featureTotals[category]) + vocabulary
+		for _, f := range fs {
+			score += math.Log((float64(cl.features[category][f]) + 1) / denominator)
+		}
+		scores[category] = score
+		if score > bestScore {
+			best, bestScore = category, score
+		}
+	}
+
+	// Posterior of the best category
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp(score - bestScore)
+	}
+	posterior := 1 / sum
+
+	// Trust a prediction less when it rests on words seen only once or twice
+	support := 0
+	for _, f := range fs {
+		if isWord(f) {
+			support = max(support, cl.features[best][f])
+		}
+	}
+	confidence := posterior * float64(support) / float64(support+minSupport)
+
+	return best, confidence
+}
+
+// features turns a transaction into the words of its description plus its
+// amount range and type
+func features(description string, amount float64, transType budget.TransactionType) []string {
+	seen := make(map[string]bool)
+	var fs []string
+	for _, word := range words(description) {
+		if !seen[word] {
+			seen[word] = true
+			fs = append(fs, word)
+		}
+	}
+	return append(fs, amountBucket(amount), "type:"+string(transType))
+}
+
+// words splits a description into lowercase words, leaving out numbers and
+// single letters
+func words(description string) []string {
+	var result []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len(word) >= 2 {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+func isWord(feature string) bool {
+	return !strings.Contains(feature, ":")
+}
+
+// amountBucket groups amounts by order of magnitude
+func amountBucket(amount float64) string {
+	amount = math.Abs(amount)
+	switch {
+	case amount < 10:
+		return "amount:<10"
+	case amount < 50:
+		return "amount:<50"
+	case amount < 100:
+		return "amount:<100"
+	case amount < 500:
+		return "amount:<500"
+	case amount < 1000:
+		return "amount:<1000"
+	default:
+		return "amount:1000+"
+	}
+}
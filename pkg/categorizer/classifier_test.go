@@ -0,0 +1,86 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestClassifierPredict(t *testing.T) {
+	spent := func(description, category string, amount float64) budget.Transaction {
+		return budget.Transaction{Description: description, Category: category, Amount: amount, Type: budget.Expense}
+	}
+	history := []budget.Transaction{
+		spent("Joe's Coffee House", "Coffee", 4.5),
+		spent("Joe's Coffee House", "Coffee", 5.25),
+		spent("Joe's Coffee House", "Coffee", 3.75),
+		spent("Green Valley Market", "Groceries", 62.1),
+		spent("Green Valley Market", "Groceries", 48.9),
+		spent("Corner shop", "Uncategorized", 3),
+	}
+
+	tests := []struct {
+		name          string
+		description   string
+		amount        float64
+		want          string
+		minConfidence float64
+	}{
+		{"seen merchant", "JOE'S COFFEE HOUSE #4", 4, "Coffee", 0.5},
+		{"other merchant", "Green Valley Market 0012", 55, "Groceries", 0.4},
+		{"one shared word", "Valley Gas", 40, "Groceries", 0},
+		{"unseen words", "Spotify Premium", 9.99, "", 0},
+		{"uncategorized not learned", "Corner shop", 3, "", 0},
+	}
+
+	cl := NewClassifier()
+	for _, t := range history {
+		cl.Learn(t)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, confidence := cl.Predict(tt.description, tt.amount, budget.Expense)
+			if got != tt.want {
+				t.Errorf("Predict(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+			if confidence < tt.minConfidence || confidence > 1 {
+				t.Errorf("confidence = %v, want at least %v", confidence, tt.minConfidence)
+			}
+		})
+	}
+}
+
+func TestClassifierSupport(t *testing.T) {
+	cl := NewClassifier()
+	coffee := budget.Transaction{Description: "Joe's Coffee", Category: "Coffee", Amount: 4, Type: budget.Expense}
+	cl.Learn(coffee)
+	_, once := cl.Predict("Joe's Coffee", 4, budget.Expense)
+	cl.Learn(coffee)
+	cl.Learn(coffee)
+	_, thrice := cl.Predict("Joe's Coffee", 4, budget.Expense)
+	if thrice <= once {
+		t.Errorf("confidence after three = %v, not above after one = %v", thrice, once)
+	}
+}
+
+func TestClassifierForget(t *testing.T) {
+	cl := NewClassifier()
+	before := budget.Transaction{Description: "Acme Hardware", Category: "Shopping", Amount: 20, Type: budget.Expense}
+	cl.Learn(before)
+	after := before
+	after.Category = "Home"
+	cl.Forget(before)
+	cl.Learn(after)
+
+	if got, _ := cl.Predict("Acme Hardware", 20, budget.Expense); got != "Home" {
+		t.Errorf("Predict() = %q after recategorizing, want Home", got)
+	}
+	if categories := cl.Categories(); len(categories) != 1 {
+		t.Errorf("Categories() = %v, want only Home", categories)
+	}
+	// Forgetting what was never learned changes nothing
+	cl.Forget(before)
+	if got, _ := cl.Predict("Acme Hardware", 20, budget.Expense); got != "Home" {
+		t.Errorf("Predict() = %q after a second forget, want Home", got)
+	}
+}
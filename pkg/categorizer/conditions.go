@@ -0,0 +1,286 @@
+package categorizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// Condition tests more of a transaction than its description, amount and
+// type. Every test that is set has to pass; All, Any and Not combine other
+// conditions, so a rule can say "from the Checking account, and on a
+// weekend or the first of the month, but not from Chase".
+type Condition struct {
+	// Source is the format the transaction was imported with, or
+	// ManualSource
+	Source  string `json:"source,omitempty"`
+	Account string `json:"account,omitempty"`
+	// DaysOfMonth and Weekdays ("mon" to "sun") test the transaction's date
+	DaysOfMonth []int    `json:"days_of_month,omitempty"`
+	Weekdays    []string `json:"weekdays,omitempty"`
+	// From and To bound the date, both included, written like 2024-01-31
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// OriginalDescription is a pattern for the description as the bank
+	// wrote it, before any edits
+	OriginalDescription string `json:"original_description,omitempty"`
+
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty"`
+}
+
+// RuleActions are changes a rule makes to the transactions it categorizes,
+// besides setting the category
+type RuleActions struct {
+	Payee string `json:"payee,omitempty"`
+	// Tags are added to the transaction's own
+	Tags []string `json:"tags,omitempty"`
+	// Split shares the amount out to other categories; what isn't shared
+	// stays in the rule's category
+	Split []SplitShare `json:"split,omitempty"`
+	// Transfer marks the transaction as a transfer between your accounts
+	Transfer bool `json:"transfer,omitempty"`
+}
+
+// SplitShare is the percentage of a transaction's amount a split gives to a
+// category
+type SplitShare struct {
+	Category string  `json:"category"`
+	Percent  float64 `json:"percent"`
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// subject is a transaction being categorized, with its descriptions
+// lowercased once for every rule
+type subject struct {
+	budget.Transaction
+	description string
+	original    string
+}
+
+func newSubject(t budget.Transaction) subject {
+	s := subject{Transaction: t, description: strings.ToLower(strings.TrimSpace(t.Description))}
+	s.original = s.description
+	if t.OriginalDescription != "" {
+		s.original = strings.ToLower(strings.TrimSpace(t.OriginalDescription))
+	}
+	return s
+}
+
+// matches reports whether the transaction passes the condition
+func (c Condition) matches(s subject) bool {
+	if c.Source != "" && !strings.EqualFold(c.Source, source(s.Transaction)) {
+		return false
+	}
+	if c.Account != "" && !strings.EqualFold(c.Account, s.Account) {
+		return false
+	}
+	if len(c.DaysOfMonth) > 0 && !slices.Contains(c.DaysOfMonth, s.Date.Day()) {
+		return false
+	}
+	if len(c.Weekdays) > 0 && !slices.ContainsFunc(c.Weekdays, func(day string) bool {
+		return strings.EqualFold(day, weekdays[s.Date.Weekday()])
+	}) {
+		return false
+	}
+	if from, err := parseConditionDate(c.From); err == nil && !from.IsZero() && s.Date.Before(from) {
+		return false
+	}
+	if to, err := parseConditionDate(c.To); err == nil && !to.IsZero() && !s.Date.Before(to.AddDate(0, 0, 1)) {
+		return false
+	}
+	if c.OriginalDescription != "" {
+		re, err := compilePattern(c.OriginalDescription)
+		if err != nil || !re.MatchString(s.original) {
+			return false
+		}
+	}
+	for _, sub := range c.All {
+		if !sub.matches(s) {
+			return false
+		}
+	}
+	if len(c.Any) > 0 && !slices.ContainsFunc(c.Any, func(sub Condition) bool { return sub.matches(s) }) {
+		return false
+	}
+	if c.Not != nil && c.Not.matches(s) {
+		return false
+	}
+	return true
+}
+
+func parseConditionDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// Validate checks the condition and every condition inside it
+func (c Condition) Validate() error {
+	for _, day := range c.DaysOfMonth {
+		if day < 1 || day > 31 {
+			return fmt.Errorf("day of month %d is not between 1 and 31", day)
+		}
+	}
+	for _, day := range c.Weekdays {
+		if !slices.Contains(weekdays, strings.ToLower(day)) {
+			return fmt.Errorf("unknown weekday %q: use %s", day, strings.Join(weekdays, ", "))
+		}
+	}
+	from, err := parseConditionDate(c.From)
+	if err != nil {
+		return fmt.Errorf("invalid from date %q: write it like 2024-01-31", c.From)
+	}
+	to, err := parseConditionDate(c.To)
+	if err != nil {
+		return fmt.Errorf("invalid to date %q: write it like 2024-01-31", c.To)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errors.New("the to date is before the from date")
+	}
+	if c.OriginalDescription != "" {
+		if err := ValidatePattern(c.OriginalDescription); err != nil {
+			return fmt.Errorf("original description: %w", err)
+		}
+	}
+	for _, sub := range slices.Concat(c.All, c.Any) {
+		if err := sub.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Not != nil {
+		return c.Not.Validate()
+	}
+	return nil
+}
+
+// String describes the condition, like "account Checking and weekday sat
+// or sun"
+func (c Condition) String() string {
+	var tests []string
+	if c.Source != "" {
+		tests = append(tests, "source "+c.Source)
+	}
+	if c.Account != "" {
+		tests = append(tests, "account "+c.Account)
+	}
+	if len(c.DaysOfMonth) > 0 {
+		days := make([]string, len(c.DaysOfMonth))
+		for i, day := range c.DaysOfMonth {
+			days[i] = fmt.Sprint(day)
+		}
+		tests = append(tests, "day "+strings.Join(days, " or "))
+	}
+	if len(c.Weekdays) > 0 {
+		tests = append(tests, "weekday "+strings.Join(c.Weekdays, " or "))
+	}
+	if c.From != "" {
+		tests = append(tests, "from "+c.From)
+	}
+	if c.To != "" {
+		tests = append(tests, "to "+c.To)
+	}
+	if c.OriginalDescription != "" {
+		tests = append(tests, fmt.Sprintf("original description %q", c.OriginalDescription))
+	}
+	for _, sub := range c.All {
+		tests = append(tests, "("+sub.String()+")")
+	}
+	if len(c.Any) > 0 {
+		alternatives := make([]string, len(c.Any))
+		for i, sub := range c.Any {
+			alternatives[i] = sub.String()
+		}
+		tests = append(tests, "("+strings.Join(alternatives, " or ")+")")
+	}
+	if c.Not != nil {
+		tests = append(tests, "not ("+c.Not.String()+")")
+	}
+	if len(tests) == 0 {
+		return "always"
+	}
+	return strings.Join(tests, " and ")
+}
+
+// Validate checks the actions can be applied
+func (a RuleActions) Validate() error {
+	total := 0.0
+	for _, share := range a.Split {
+		if strings.TrimSpace(share.Category) == "" {
+			return errors.New("every split needs a category")
+		}
+		if share.Percent <= 0 {
+			return fmt.Errorf("the split to %s needs a percentage above 0", share.Category)
+		}
+		total += share.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("the split shares add up to %g%%, more than 100%%", total)
+	}
+	return nil
+}
+
+// Apply makes the actions' changes to t, whose category is the rule's
+func (a RuleActions) Apply(t *budget.Transaction) {
+	if a.Payee != "" {
+		t.Payee = a.Payee
+	}
+	for _, tag := range a.Tags {
+		if !slices.Contains(t.Tags, tag) {
+			t.Tags = append(slices.Clip(t.Tags), tag)
+		}
+	}
+	if len(a.Split) > 0 {
+		t.Splits = a.splits(t.Category, t.Amount)
+	}
+	if a.Transfer {
+		t.IsTransfer = true
+	}
+}
+
+// splits shares amount out by percentage, in cents, leaving the rest in
+// category
+func (a RuleActions) splits(category string, amount float64) []budget.Split {
+	var splits []budget.Split
+	left := math.Round(amount * 100)
+	for _, share := range a.Split {
+		cents := min(math.Round(amount*share.Percent), left)
+		splits = append(splits, budget.Split{Category: share.Category, Amount: cents / 100})
+		left -= cents
+	}
+	if left > 0 {
+		splits = append(splits, budget.Split{Category: category, Amount: left / 100})
+	}
+	return splits
+}
+
+// String describes the actions, like "payee Costco, tags bulk, split 30%
+// Household"
+func (a RuleActions) String() string {
+	var actions []string
+	if a.Payee != "" {
+		actions = append(actions, "payee "+a.Payee)
+	}
+	if len(a.Tags) > 0 {
+		actions = append(actions, "tags "+strings.Join(a.Tags, " "))
+	}
+	if len(a.Split) > 0 {
+		shares := make([]string, len(a.Split))
+		for i, share := range a.Split {
+			shares[i] = fmt.Sprintf("%g%% %s", share.Percent, share.Category)
+		}
+		actions = append(actions, "split "+strings.Join(shares, ", "))
+	}
+	if a.Transfer {
+		actions = append(actions, "transfer")
+	}
+	return strings.Join(actions, ", ")
+}
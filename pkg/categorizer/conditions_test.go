@@ -0,0 +1,99 @@
+package categorizer
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestConditionMatches(t *testing.T) {
+	// 2024-01-06 is a Saturday
+	saturday := budget.Transaction{
+		Description:         "costco",
+		OriginalDescription: "COSTCO WHSE #123",
+		Account:             "Checking",
+		ImportSource:        "Chase",
+		IsImported:          true,
+		Date:                time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"empty", Condition{}, true},
+		{"account", Condition{Account: "checking"}, true},
+		{"other account", Condition{Account: "Savings"}, false},
+		{"source", Condition{Source: "chase"}, true},
+		{"manual source", Condition{Source: ManualSource}, false},
+		{"weekday", Condition{Weekdays: []string{"sat", "sun"}}, true},
+		{"other weekday", Condition{Weekdays: []string{"mon"}}, false},
+		{"day of month", Condition{DaysOfMonth: []int{1, 6}}, true},
+		{"other day of month", Condition{DaysOfMonth: []int{15}}, false},
+		{"inside range", Condition{From: "2024-01-01", To: "2024-01-06"}, true},
+		{"before range", Condition{From: "2024-01-07"}, false},
+		{"after range", Condition{To: "2024-01-05"}, false},
+		{"original description", Condition{OriginalDescription: "whse"}, true},
+		{"edited description ignored", Condition{OriginalDescription: "^costco$"}, false},
+		{"all", Condition{All: []Condition{{Account: "Checking"}, {Source: "Chase"}}}, true},
+		{"all failing", Condition{All: []Condition{{Account: "Checking"}, {Source: "JSON"}}}, false},
+		{"any", Condition{Any: []Condition{{Weekdays: []string{"mon"}}, {DaysOfMonth: []int{6}}}}, true},
+		{"any failing", Condition{Any: []Condition{{Weekdays: []string{"mon"}}, {DaysOfMonth: []int{7}}}}, false},
+		{"not", Condition{Not: &Condition{Account: "Savings"}}, true},
+		{"not failing", Condition{Not: &Condition{Account: "Checking"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.matches(newSubject(saturday)); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleActionsApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions RuleActions
+		check   func(t *testing.T, got budget.Transaction)
+	}{
+		{"payee", RuleActions{Payee: "Costco"}, func(t *testing.T, got budget.Transaction) {
+			if got.Payee != "Costco" {
+				t.Errorf("payee = %q", got.Payee)
+			}
+		}},
+		{"tags added once", RuleActions{Tags: []string{"bulk", "shop"}}, func(t *testing.T, got budget.Transaction) {
+			if !slices.Equal(got.Tags, []string{"shop", "bulk"}) {
+				t.Errorf("tags = %v", got.Tags)
+			}
+		}},
+		{"split", RuleActions{Split: []SplitShare{{Category: "Household", Percent: 30}}}, func(t *testing.T, got budget.Transaction) {
+			want := []budget.Split{{Category: "Household", Amount: 30.03}, {Category: "Groceries", Amount: 70.07}}
+			if !slices.Equal(got.Splits, want) {
+				t.Errorf("splits = %v, want %v", got.Splits, want)
+			}
+		}},
+		{"transfer", RuleActions{Transfer: true}, func(t *testing.T, got budget.Transaction) {
+			if !got.IsTransfer {
+				t.Error("not marked as a transfer")
+			}
+		}},
+		{"no transfer action", RuleActions{Payee: "Costco"}, func(t *testing.T, got budget.Transaction) {
+			if got.IsTransfer {
+				t.Error("marked as a transfer")
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := budget.Transaction{Category: "Groceries", Amount: 100.10, Tags: []string{"shop"}}
+			tt.actions.Apply(&transaction)
+			tt.check(t, transaction)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package categorizer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// Why a rule didn't apply to a transaction
+const (
+	SkipInactive   = "rule is off"
+	SkipType       = "wrong transaction type"
+	SkipBelowMin   = "amount below minimum"
+	SkipAboveMax   = "amount above maximum"
+	SkipNoMatch    = "neither pattern nor keywords match"
+	SkipBadPattern = "pattern is not a valid regular expression"
+	SkipConditions = "conditions not met"
+)
+
+// How a rule matched, and the confidence each kind of match starts with
+const (
+	matchedPattern    = "pattern"
+	matchedKeyword    = "keyword"
+	matchedConditions = "conditions"
+	patternScore      = 0.9
+	keywordScore      = 0.8
+	conditionsScore   = 0.8
+)
+
+// RuleEvaluation is how one rule fared against a transaction
+type RuleEvaluation struct {
+	Rule    CategorizationRule
+	Matched bool
+	// MatchedBy is "pattern", "keyword" or, for a rule with only
+	// conditions, "conditions"; Keyword holds the keyword found
+	MatchedBy string
+	Keyword   string
+	// SkipReason says why the rule didn't match
+	SkipReason string
+	// BaseConfidence is the match's confidence before the rule's priority
+	// is applied, Confidence after. DefaultConfidence is what BaseConfidence
+	// starts as for the kind of match, before it is calibrated against how
+	// often the user kept the rule's suggestions. Bonus is added after the
+	// priority for an exact merchant match.
+	DefaultConfidence float64
+	BaseConfidence    float64
+	Bonus             float64
+	Confidence        float64
+}
+
+// evaluate checks the rule against a transaction
+func (rule CategorizationRule) evaluate(s subject) RuleEvaluation {
+	var pattern compiledPattern
+	if rule.Pattern != "" {
+		pattern.re, pattern.err = compilePattern(rule.Pattern)
+	}
+	return rule.evaluateCompiled(s, pattern)
+}
+
+// evaluateCompiled is evaluate with the rule's pattern already compiled
+func (rule CategorizationRule) evaluateCompiled(s subject, pattern compiledPattern) RuleEvaluation {
+	evaluation := RuleEvaluation{Rule: rule}
+	description := s.description
+	switch {
+	case !rule.IsActive:
+		evaluation.SkipReason = SkipInactive
+		return evaluation
+	case rule.TransactionType != "" && rule.TransactionType != s.Type:
+		evaluation.SkipReason = SkipType
+		return evaluation
+	case rule.MinAmount > 0 && s.Amount < rule.MinAmount:
+		evaluation.SkipReason = SkipBelowMin
+		return evaluation
+	case rule.MaxAmount > 0 && s.Amount > rule.MaxAmount:
+		evaluation.SkipReason = SkipAboveMax
+		return evaluation
+	case rule.Conditions != nil && !rule.Conditions.matches(s):
+		evaluation.SkipReason = SkipConditions
+		return evaluation
+	case rule.Pattern == "" && len(rule.Keywords) == 0:
+		evaluation.Matched, evaluation.MatchedBy, evaluation.BaseConfidence = true, matchedConditions, conditionsScore
+		evaluation.DefaultConfidence = evaluation.BaseConfidence
+		evaluation.Confidence, evaluation.Bonus = rule.scale(description, evaluation.BaseConfidence)
+		return evaluation
+	}
+
+	// Try regex pattern first
+	evaluation.SkipReason = SkipNoMatch
+	if rule.Pattern != "" {
+		if pattern.err != nil {
+			evaluation.SkipReason = SkipBadPattern
+		} else if pattern.re.MatchString(description) {
+			evaluation.Matched, evaluation.MatchedBy, evaluation.BaseConfidence = true, matchedPattern, patternScore
+		}
+	}
+
+	// Try keywords if pattern didn't match
+	if !evaluation.Matched {
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(description, strings.ToLower(keyword)) {
+				evaluation.Matched, evaluation.MatchedBy, evaluation.BaseConfidence = true, matchedKeyword, keywordScore
+				evaluation.Keyword = keyword
+				break
+			}
+		}
+	}
+
+	if evaluation.Matched {
+		evaluation.SkipReason = ""
+		evaluation.DefaultConfidence = evaluation.BaseConfidence
+		evaluation.Confidence, evaluation.Bonus = rule.scale(description, evaluation.BaseConfidence)
+	}
+	return evaluation
+}
+
+// scale adjusts a match's confidence for the rule's priority, and returns
+// it with the bonus it includes
+func (rule CategorizationRule) scale(description string, confidence float64) (float64, float64) {
+	confidence = confidence * (float64(rule.Priority) / 100.0)
+
+	// Add confidence for exact matches
+	bonus := 0.0
+	if strings.Contains(description, "amazon") && rule.Category == "Shopping" {
+		bonus = 0.1
+	}
+	return math.Min(confidence+bonus, 1.0), bonus
+}
+
+// Explanation shows how a transaction was categorized
+type Explanation struct {
+	// Evaluations has every rule, in the order they were tried
+	Evaluations []RuleEvaluation
+	// Winner is the index of the winning rule in Evaluations, or -1
+	Winner         int
+	RuleCategory   string
+	RuleConfidence float64
+	// What the classifier learned from the user's transactions suggests
+	LearnedCategory   string
+	LearnedConfidence float64
+	// The category CategorizeTransaction returns
+	Category   string
+	Confidence float64
+	// Reason says in words why Category won
+	Reason string
+}
+
+// Explain categorizes a transaction like Categorize and records every rule
+// it tried and why the result won
+func (c *Categorizer) Explain(t budget.Transaction) Explanation {
+	s := newSubject(t)
+	explanation := Explanation{Winner: -1, RuleCategory: "Uncategorized"}
+
+	c.mu.RLock()
+	for i, rule := range c.rules {
+		evaluation := c.calibrate(rule.evaluateCompiled(s, c.index.patterns[i]), c.index.keys[i], s.description)
+		explanation.Evaluations = append(explanation.Evaluations, evaluation)
+		if evaluation.Matched && evaluation.Confidence > explanation.RuleConfidence {
+			explanation.Winner = i
+			explanation.RuleCategory = rule.Category
+			explanation.RuleConfidence = evaluation.Confidence
+		}
+	}
+	c.mu.RUnlock()
+	explanation.RuleConfidence = math.Min(explanation.RuleConfidence, 1.0)
+
+	explanation.LearnedCategory, explanation.LearnedConfidence = c.learned.Predict(t.Description, t.Amount, t.Type)
+	result := c.Categorize(t)
+	explanation.Category, explanation.Confidence = result.Category, result.Confidence
+	explanation.Reason = explanation.reason()
+	return explanation
+}
+
+func (e Explanation) reason() string {
+	var ruleReason string
+	if e.Winner >= 0 {
+		winner := e.Evaluations[e.Winner]
+		matches, ties := 0, 0
+		for i, evaluation := range e.Evaluations {
+			if evaluation.Matched {
+				matches++
+				if i != e.Winner && evaluation.Confidence == winner.Confidence && evaluation.Rule.Category != winner.Rule.Category {
+					ties++
+				}
+			}
+		}
+		ruleReason = fmt.Sprintf("%s matched by %s with %.0f%% × priority %d",
+			winner.Rule.describe(), winner.MatchedBy, winner.BaseConfidence*100, winner.Rule.Priority)
+		if winner.Bonus > 0 {
+			ruleReason += fmt.Sprintf(" + %.0f%% for an exact match", winner.Bonus*100)
+		}
+		ruleReason += fmt.Sprintf(" = %.0f%%", winner.Confidence*100)
+		if winner.BaseConfidence*float64(winner.Rule.Priority)/100+winner.Bonus > 1 {
+			ruleReason += " at most"
+		}
+		if winner.BaseConfidence != winner.DefaultConfidence {
+			ruleReason += fmt.Sprintf(" (%.0f%% calibrated from %.0f%% by how often its suggestions were kept)",
+				winner.BaseConfidence*100, winner.DefaultConfidence*100)
+		}
+		switch {
+		case ties > 0:
+			others := "rule"
+			if ties > 1 {
+				others = "rules"
+			}
+			ruleReason += fmt.Sprintf(", tied with %d other %s but tried first", ties, others)
+		case matches > 1:
+			ruleReason += fmt.Sprintf(", the highest of %d matching rules", matches)
+		}
+	}
+
+	switch {
+	case e.Winner < 0 && e.LearnedCategory == "":
+		return "no rule matched and nothing similar has been categorized before"
+	case e.LearnedCategory == "":
+		return ruleReason
+	case e.Winner < 0:
+		return fmt.Sprintf("no rule matched; learned from similar transactions with %.0f%% confidence", e.LearnedConfidence*100)
+	case e.LearnedCategory == e.RuleCategory:
+		return fmt.Sprintf("%s, and similar transactions agree (%.0f%%), so together %.0f%%",
+			ruleReason, e.LearnedConfidence*100, e.Confidence*100)
+	case e.Category == e.LearnedCategory:
+		return fmt.Sprintf("similar transactions were %s with %.0f%% confidence, more than the %s",
+			e.LearnedCategory, e.LearnedConfidence*100, ruleReason)
+	default:
+		return fmt.Sprintf("%s, more than the %.0f%% learned from similar transactions (%s)",
+			ruleReason, e.LearnedConfidence*100, e.LearnedCategory)
+	}
+}
@@ -0,0 +1,80 @@
+package categorizer
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestExplain(t *testing.T) {
+	rules := []CategorizationRule{
+		{Pattern: ".*netflix.*", Category: "Entertainment", Priority: 100, IsActive: true},
+		{Pattern: ".*amazon.*", Category: "Shopping", Priority: 80, IsActive: true},
+		{Pattern: ".*amazon prime.*", Category: "Subscriptions", Priority: 100, IsActive: false},
+		{Keywords: []string{"bakery"}, Category: "Groceries", Priority: 50, IsActive: true},
+		{Keywords: []string{"bakery"}, Category: "Dining", Priority: 50, IsActive: true},
+		{Pattern: ".*bakery.*", Category: "Catering", Priority: 90, IsActive: true, MinAmount: 100},
+		{Pattern: ".*payroll.*", Category: "Income", Priority: 100, IsActive: true, TransactionType: budget.Income},
+	}
+
+	tests := []struct {
+		name           string
+		description    string
+		amount         float64
+		wantCategory   string
+		wantConfidence float64
+		wantReason     []string
+		wantSkips      map[string]string
+	}{
+		{
+			"pattern", "NETFLIX.COM", 15.49, "Entertainment", 0.9,
+			[]string{`the Entertainment rule ".*netflix.*" matched by pattern with 90% × priority 100 = 90%`},
+			nil,
+		},
+		{
+			// The bonus is added after the priority, so it is its own term
+			"exact match bonus", "AMAZON PRIME MEMBERSHIP", 14.99, "Shopping", 0.82,
+			[]string{"90% × priority 80 + 10% for an exact match = 82%"},
+			map[string]string{"Subscriptions": SkipInactive},
+		},
+		{
+			"tied keywords", "Joe's Bakery", 12, "Groceries", 0.4,
+			[]string{"matched by keyword with 80% × priority 50 = 40%", "tied with 1 other rule but tried first"},
+			map[string]string{"Catering": SkipBelowMin, "Income": SkipType, "Entertainment": SkipNoMatch},
+		},
+		{
+			"no match", "Corner shop", 3, "Uncategorized", 0,
+			[]string{"no rule matched and nothing similar has been categorized before"},
+			nil,
+		},
+	}
+
+	c := testCategorizer(rules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := c.Explain(budget.Transaction{Description: tt.description, Amount: tt.amount, Type: budget.Expense})
+			if e.Category != tt.wantCategory || math.Abs(e.Confidence-tt.wantConfidence) > 1e-9 {
+				t.Errorf("Explain() = %s at %.3f, want %s at %.3f", e.Category, e.Confidence, tt.wantCategory, tt.wantConfidence)
+			}
+			// Explain agrees with Categorize
+			if result := c.Categorize(budget.Transaction{Description: tt.description, Amount: tt.amount, Type: budget.Expense}); result.Category != e.Category || math.Abs(result.Confidence-e.Confidence) > 1e-9 {
+				t.Errorf("Categorize() = %s at %.3f, Explain() = %s at %.3f", result.Category, result.Confidence, e.Category, e.Confidence)
+			}
+			for _, want := range tt.wantReason {
+				if !strings.Contains(e.Reason, want) {
+					t.Errorf("reason = %q, want it to contain %q", e.Reason, want)
+				}
+			}
+			if len(e.Evaluations) != len(rules) {
+				t.Fatalf("%d evaluations, want one for each of the %d rules", len(e.Evaluations), len(rules))
+			}
+			for _, evaluation := range e.Evaluations {
+				if want, ok := tt.wantSkips[evaluation.Rule.Category]; ok && evaluation.SkipReason != want {
+					t.Errorf("%s skipped with %q, want %q", evaluation.Rule.Category, evaluation.SkipReason, want)
+				}
+			}
+		})
+	}
+}
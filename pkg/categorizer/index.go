@@ -0,0 +1,304 @@
+package categorizer
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// compiledPatterns caches patterns by their text, so each is compiled once
+// however many transactions it is matched against
+var compiledPatterns sync.Map
+
+type compiledPattern struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// compilePattern compiles a rule or condition pattern as it is matched,
+// against a lowercased description. The result is only for MatchString:
+// the .* that patterns usually start and end with is left out, as it can't
+// change whether they match and slows matching down a lot.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := compiledPatterns.Load(pattern); ok {
+		compiled := cached.(compiledPattern)
+		return compiled.re, compiled.err
+	}
+	lower := strings.ToLower(pattern)
+	re, err := regexp.Compile(lower)
+	if err == nil {
+		if parsed, err := syntax.Parse(lower, syntax.Perl); err == nil {
+			if trimmed, err := regexp.Compile(trimAnyText(parsed).String()); err == nil {
+				re = trimmed
+			}
+		}
+	}
+	compiledPatterns.Store(pattern, compiledPattern{re, err})
+	return re, err
+}
+
+// trimAnyText removes the .* from the start and end of re and of each of
+// its alternatives
+func trimAnyText(re *syntax.Regexp) *syntax.Regexp {
+	isAnyText := func(sub *syntax.Regexp) bool {
+		return sub.Op == syntax.OpStar && (sub.Sub[0].Op == syntax.OpAnyCharNotNL || sub.Sub[0].Op == syntax.OpAnyChar)
+	}
+	switch re.Op {
+	case syntax.OpAlternate:
+		trimmed := *re
+		trimmed.Sub = make([]*syntax.Regexp, len(re.Sub))
+		for i, sub := range re.Sub {
+			trimmed.Sub[i] = trimAnyText(sub)
+		}
+		return &trimmed
+	case syntax.OpConcat:
+		subs := re.Sub
+		for len(subs) > 0 && isAnyText(subs[0]) {
+			subs = subs[1:]
+		}
+		for len(subs) > 0 && isAnyText(subs[len(subs)-1]) {
+			subs = subs[:len(subs)-1]
+		}
+		switch len(subs) {
+		case 0:
+			return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+		case 1:
+			return subs[0]
+		}
+		trimmed := *re
+		trimmed.Sub = subs
+		return &trimmed
+	}
+	if isAnyText(re) {
+		return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	}
+	return re
+}
+
+// ruleIndex narrows down the rules worth trying for a description. Most
+// rules can only match when some text appears in the description: one of
+// their keywords, or a word from each alternative of their pattern. An
+// Aho-Corasick automaton finds all of those in one pass, and only their
+// rules are tried, along with the rules that can't be narrowed down.
+type ruleIndex struct {
+	// patterns holds each rule's compiled pattern and keys its key in the
+	// stats
+	patterns  []compiledPattern
+	keys      []string
+	automaton *ahoCorasick
+	// triggers holds the rule each string in the automaton belongs to
+	triggers []int
+	// always are the rules tried for every description
+	always []int
+}
+
+// newRuleIndex indexes the active rules
+func newRuleIndex(rules []CategorizationRule) *ruleIndex {
+	index := &ruleIndex{patterns: make([]compiledPattern, len(rules)), keys: make([]string, len(rules))}
+	var texts []string
+	for i, rule := range rules {
+		index.keys[i] = rule.key()
+		if !rule.IsActive {
+			continue
+		}
+		if rule.Pattern != "" {
+			index.patterns[i].re, index.patterns[i].err = compilePattern(rule.Pattern)
+		}
+		required, ok := rule.requiredText()
+		if !ok {
+			index.always = append(index.always, i)
+			continue
+		}
+		for _, text := range required {
+			texts = append(texts, text)
+			index.triggers = append(index.triggers, i)
+		}
+	}
+	index.automaton = newAhoCorasick(texts)
+	return index
+}
+
+// candidates returns, in order, the indexes of the rules that could match
+// a lowercased description
+func (index *ruleIndex) candidates(description string) []int {
+	candidates := slices.Clone(index.always)
+	index.automaton.find(description, func(id, _ int) {
+		candidates = append(candidates, index.triggers[id])
+	})
+	slices.Sort(candidates)
+	return slices.Compact(candidates)
+}
+
+// requiredText lists text, one piece of which has to be in a lowercased
+// description for the rule to match it. It is false when there is no such
+// text, because the rule has only conditions or a pattern too complicated
+// to read it from.
+func (rule CategorizationRule) requiredText() ([]string, bool) {
+	var required []string
+	if rule.Pattern != "" {
+		re, err := syntax.Parse(strings.ToLower(rule.Pattern), syntax.Perl)
+		if err != nil {
+			// It never matches, so only keywords can
+			re = nil
+		}
+		if re != nil {
+			alternatives, ok := requiredAlternatives(re.Simplify())
+			if !ok {
+				return nil, false
+			}
+			for _, alternative := range alternatives {
+				// Of the text either side of each .*, the longest is the
+				// most selective
+				longest := ""
+				for _, fragment := range strings.Split(alternative, anyText) {
+					if len(fragment) > len(longest) {
+						longest = fragment
+					}
+				}
+				if longest == "" {
+					return nil, false
+				}
+				required = append(required, longest)
+			}
+		}
+	}
+
+	for _, keyword := range rule.Keywords {
+		keyword = strings.ToLower(keyword)
+		if keyword == "" {
+			return nil, false
+		}
+		required = append(required, keyword)
+	}
+	return required, len(required) > 0
+}
+
+// requiredAlternatives is expand for finding required text: a part of a
+// concatenation that can't be expanded, like \d+, becomes a gap as if it
+// were .*
+func requiredAlternatives(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpCapture:
+		return requiredAlternatives(re.Sub[0])
+	case syntax.OpAlternate:
+		var result []string
+		for _, sub := range re.Sub {
+			parts, ok := requiredAlternatives(sub)
+			if !ok || len(result)+len(parts) > maxLiterals {
+				return nil, false
+			}
+			result = append(result, parts...)
+		}
+		return result, true
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, sub := range re.Sub {
+			parts, ok := requiredAlternatives(sub)
+			if !ok || len(result)*len(parts) > maxLiterals {
+				parts = []string{anyText}
+			}
+			var next []string
+			for _, prefix := range result {
+				for _, part := range parts {
+					next = append(next, prefix+part)
+				}
+			}
+			result = next
+		}
+		return result, true
+	}
+	return expand(re)
+}
+
+// ahoCorasick finds which of a set of strings occur in a text in a single
+// pass over it. The automaton is a table with a column for each byte the
+// strings use, so each byte of the text takes one lookup.
+type ahoCorasick struct {
+	// class numbers the bytes the strings use from 1; 0 is every other
+	// byte. All 256 can be used, so it is wider than a byte.
+	class   [256]uint16
+	classes int
+	// next is the node after each node and byte class
+	next []int32
+	// output holds the ids of the strings that end at a node, directly or
+	// as a suffix of its text
+	output [][]int32
+}
+
+func newAhoCorasick(texts []string) *ahoCorasick {
+	a := &ahoCorasick{classes: 1}
+	for _, text := range texts {
+		for i := 0; i < len(text); i++ {
+			if a.class[text[i]] == 0 {
+				a.class[text[i]] = uint16(a.classes)
+				a.classes++
+			}
+		}
+	}
+
+	// The trie of the strings, with -1 for missing edges
+	trie := [][]int32{a.newNode()}
+	a.output = [][]int32{nil}
+	for id, text := range texts {
+		node := int32(0)
+		for i := 0; i < len(text); i++ {
+			c := a.class[text[i]]
+			if trie[node][c] < 0 {
+				trie[node][c] = int32(len(trie))
+				trie = append(trie, a.newNode())
+				a.output = append(a.output, nil)
+			}
+			node = trie[node][c]
+		}
+		a.output[node] = append(a.output[node], int32(id))
+	}
+
+	// Breadth first, so the fail link of a node, the node for the longest
+	// proper suffix of its text, is complete before its children need it
+	a.next = make([]int32, len(trie)*a.classes)
+	fail := make([]int32, len(trie))
+	queue := []int32{0}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c := range a.classes {
+			child := trie[node][c]
+			if child < 0 {
+				// Follow the fail link; the root stays at the root
+				if node > 0 {
+					a.next[int(node)*a.classes+c] = a.next[int(fail[node])*a.classes+c]
+				}
+				continue
+			}
+			a.next[int(node)*a.classes+c] = child
+			if node > 0 {
+				fail[child] = a.next[int(fail[node])*a.classes+c]
+			}
+			a.output[child] = append(a.output[child], a.output[fail[child]]...)
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+func (a *ahoCorasick) newNode() []int32 {
+	node := make([]int32, a.classes)
+	for c := range node {
+		node[c] = -1
+	}
+	return node
+}
+
+// find calls found with the id of every string in text, once per place
+// it occurs, and the index in text just past where it ends
+func (a *ahoCorasick) find(text string, found func(id, end int)) {
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		node = a.next[int(node)*a.classes+int(a.class[text[i]])]
+		for _, id := range a.output[node] {
+			found(int(id), i+1)
+		}
+	}
+}
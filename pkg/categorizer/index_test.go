@@ -0,0 +1,220 @@
+package categorizer
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestAhoCorasickFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		input string
+		want  []int
+	}{
+		{"overlapping", []string{"he", "she", "his", "hers"}, "ushers", []int{0, 1, 3}},
+		{"suffix through fail link", []string{"gas", "gas station", "station"}, "shell gas station 42", []int{0, 1, 2}},
+		{"repeated", []string{"ab"}, "abab", []int{0, 0}},
+		{"inside a word", []string{"rent"}, "current account", []int{0}},
+		{"no match", []string{"netflix", "spotify"}, "amazon prime", nil},
+		{"empty set", nil, "anything", nil},
+		{"every byte", everyByte(), "\xfe\xff", []int{254, 255, 256}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			newAhoCorasick(tt.texts).find(tt.input, func(id, _ int) { got = append(got, id) })
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("find(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleRequiredText(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CategorizationRule
+		want    []string
+		indexed bool
+	}{
+		{"alternatives", CategorizationRule{Pattern: ".*Uber|Lyft.*"}, []string{"uber", "lyft"}, true},
+		{"longest fragment", CategorizationRule{Pattern: ".*whole.*foods market.*"}, []string{"foods market"}, true},
+		{"with keywords", CategorizationRule{Pattern: ".*ATM.*", Keywords: []string{"Cash Withdrawal"}}, []string{"atm", "cash withdrawal"}, true},
+		{"character class", CategorizationRule{Pattern: "gr[ae]y"}, []string{"gray", "grey"}, true},
+		{"repetition", CategorizationRule{Pattern: `\d+ main st`}, []string{" main st"}, true},
+		{"only repetition", CategorizationRule{Pattern: `\d+`}, nil, false},
+		{"optional alternative", CategorizationRule{Pattern: "(shell)?"}, nil, false},
+		{"conditions only", CategorizationRule{Conditions: &Condition{Account: "Savings"}}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, indexed := tt.rule.requiredText()
+			if indexed != tt.indexed || indexed && !slices.Equal(got, tt.want) {
+				t.Errorf("requiredText() = %q, %v, want %q, %v", got, indexed, tt.want, tt.indexed)
+			}
+		})
+	}
+}
+
+// TestRuleIndexMatchesEveryRule checks that narrowing down the rules with
+// the index picks the same category as trying every rule
+func TestRuleIndexMatchesEveryRule(t *testing.T) {
+	rules := syntheticRules(300)
+	c := testCategorizer(rules)
+	everyRule := testCategorizer(rules)
+	everyRule.index = unindexed(everyRule.rules)
+
+	for _, transaction := range syntheticTransactions(2000, rules) {
+		s := newSubject(transaction)
+		got, want := c.matchRules(s), everyRule.matchRules(s)
+		if got.Category != want.Category || got.Confidence != want.Confidence {
+			t.Fatalf("%q: got %s (%.2f), trying every rule gives %s (%.2f)",
+				transaction.Description, got.Category, got.Confidence, want.Category, want.Confidence)
+		}
+	}
+}
+
+// BenchmarkCategorize categorizes 100,000 transactions against 1,000 rules
+// per iteration
+func BenchmarkCategorize(b *testing.B) {
+	rules := syntheticRules(1000)
+	c := testCategorizer(rules)
+	transactions := syntheticTransactions(100_000, rules)
+
+	for b.Loop() {
+		for _, t := range transactions {
+			c.CategorizeTransaction(t.Description, t.Amount, t.Type)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(transactions)), "ns/transaction")
+}
+
+// BenchmarkCategorizeEveryRule is BenchmarkCategorize without the index, on
+// fewer transactions, for comparison
+func BenchmarkCategorizeEveryRule(b *testing.B) {
+	rules := syntheticRules(1000)
+	c := testCategorizer(rules)
+	c.index = unindexed(c.rules)
+	transactions := syntheticTransactions(1000, rules)
+
+	for b.Loop() {
+		for _, t := range transactions {
+			c.CategorizeTransaction(t.Description, t.Amount, t.Type)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(transactions)), "ns/transaction")
+}
+
+func BenchmarkNewRuleIndex(b *testing.B) {
+	rules := syntheticRules(1000)
+	for b.Loop() {
+		newRuleIndex(rules)
+	}
+}
+
+// everyByte is a string for each byte, so the automaton uses all 256 byte
+// classes, and one more for the last two bytes together
+func everyByte() []string {
+	texts := make([]string, 256, 257)
+	for b := range texts {
+		texts[b] = string([]byte{byte(b)})
+	}
+	return append(texts, "\xfe\xff")
+}
+
+// testCategorizer is a Categorizer with rules and nothing learned, without
+// reading the user's rules file
+func testCategorizer(rules []CategorizationRule) *Categorizer {
+	c := &Categorizer{rules: slices.Clone(rules), learned: NewClassifier(), stats: newRuleStatsStore()}
+	sortRules(c.rules)
+	c.index = newRuleIndex(c.rules)
+	return c
+}
+
+// unindexed is an index that tries every rule
+func unindexed(rules []CategorizationRule) *ruleIndex {
+	index := newRuleIndex(rules)
+	index.automaton, index.triggers, index.always = newAhoCorasick(nil), nil, nil
+	for i := range rules {
+		index.always = append(index.always, i)
+	}
+	return index
+}
+
+var syllables = []string{"ka", "lo", "mi", "ne", "ru", "sa", "to", "vi", "ze", "bo", "da", "fe", "gu", "ha", "ji", "po"}
+
+// merchantName makes up a name for merchant i
+func merchantName(i int) string {
+	var name strings.Builder
+	for n := i + len(syllables); n > 0; n /= len(syllables) {
+		name.WriteString(syllables[n%len(syllables)])
+	}
+	return name.String()
+}
+
+// syntheticRules makes up n rules for made-up merchants, plus the defaults,
+// in the shapes users write: patterns with alternatives, keywords, amount
+// bounds and types, and a few patterns the index can't narrow down
+func syntheticRules(n int) []CategorizationRule {
+	rules := new(Categorizer).getDefaultRules()
+	for i := range n {
+		name := merchantName(i)
+		rule := CategorizationRule{
+			Category: fmt.Sprintf("Category %d", i%40),
+			Priority: 50 + i%50,
+			IsActive: true,
+		}
+		switch i % 10 {
+		case 0, 1, 2, 3:
+			rule.Pattern = fmt.Sprintf(".*%s|%s store.*", name, merchantName(i+n))
+		case 4, 5:
+			rule.Keywords = []string{name, name + " online"}
+		case 6:
+			rule.Pattern = fmt.Sprintf(".*%s.*market.*", name)
+			rule.MinAmount = 20
+		case 7:
+			rule.Pattern = ".*" + name + ".*"
+			rule.TransactionType = budget.Income
+		case 8:
+			rule.Pattern = fmt.Sprintf(".*%s.*", name)
+			rule.Keywords = []string{merchantName(i + 2*n)}
+		case 9:
+			rule.Pattern = fmt.Sprintf(`%s #\d+`, name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// syntheticTransactions makes up n bank descriptions, mostly naming one of
+// the rules' merchants among the usual noise
+func syntheticTransactions(n int, rules []CategorizationRule) []budget.Transaction {
+	r := rand.New(rand.NewPCG(1, 2))
+	prefixes := []string{"POS PURCHASE", "DEBIT CARD", "ACH", "ONLINE PAYMENT", "SQ *", ""}
+	transactions := make([]budget.Transaction, n)
+	for i := range transactions {
+		merchant := merchantName(r.IntN(len(rules) * 2))
+		if r.IntN(10) == 0 {
+			merchant += " market"
+		}
+		transType := budget.Expense
+		if r.IntN(8) == 0 {
+			transType = budget.Income
+		}
+		transactions[i] = budget.Transaction{
+			Description: fmt.Sprintf("%s %s #%d %s", prefixes[r.IntN(len(prefixes))], strings.ToUpper(merchant), r.IntN(10000), "SPRINGFIELD"),
+			Amount:      float64(r.IntN(100000)) / 100,
+			Type:        transType,
+		}
+	}
+	return transactions
+}
@@ -0,0 +1,480 @@
+package categorizer
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// Kinds of problem the rule linter finds, most serious first
+const (
+	LintInvalid  = "invalid"
+	LintBroad    = "too broad"
+	LintShadowed = "shadowed"
+	LintConflict = "conflict"
+	LintUnused   = "unused"
+)
+
+var lintKindOrder = map[string]int{LintInvalid: 0, LintBroad: 1, LintShadowed: 2, LintConflict: 3, LintUnused: 4}
+
+// LintFinding is a problem with one rule
+type LintFinding struct {
+	Kind string
+	// Rule is the rule's index in Rules(). Other is the rule it conflicts
+	// with or is shadowed by, or -1.
+	Rule    int
+	Other   int
+	Message string
+	// Examples are descriptions that show the problem
+	Examples []string
+}
+
+const (
+	maxLintExamples = 3
+	// maxLiterals is how many strings a pattern can stand for before it is
+	// too complicated to probe
+	maxLiterals = 32
+	// minLiteralLength is the shortest word that is safe to look for inside
+	// descriptions
+	minLiteralLength = 3
+)
+
+// lintInput is a transaction the rules are tried against
+type lintInput struct {
+	subject
+	history bool
+	// category is the one the user chose for a transaction, if they did
+	category string
+}
+
+// ruleStats is how a rule fared against every input
+type ruleStats struct {
+	matched        int
+	historyMatched int
+	wins           int
+	// disagreements are won transactions the user categorized otherwise
+	disagreements map[string]int
+	disagreeing   []string
+	// beatenBy counts, per winning rule, the inputs this rule matched but
+	// lost, with a few of them as examples
+	beatenBy map[int]int
+	examples map[int][]string
+	ties     map[int]bool
+}
+
+// LintRules checks the rules for problems: invalid rules, patterns and
+// keywords that match more than they should, rules that never win because
+// another rule always takes their transactions, rules that disagree about
+// the same transaction, and rules that never matched any of transactions.
+// Overlaps are found by trying every rule's own words and the transactions
+// against the rules, so the more history there is, the more it finds. The
+// rule index narrows down the rules tried for each, as when categorizing.
+func (c *Categorizer) LintRules(transactions []budget.Transaction) []LintFinding {
+	c.mu.RLock()
+	rules := slices.Clone(c.rules)
+	index := c.index
+	c.mu.RUnlock()
+	var findings []LintFinding
+
+	valid := make([]bool, len(rules))
+	literals := make([][]string, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			findings = append(findings, LintFinding{Kind: LintInvalid, Rule: i, Other: -1, Message: err.Error()})
+			continue
+		}
+		valid[i] = true
+		literals[i] = rule.literals()
+	}
+
+	inputs := lintInputs(rules, valid, literals, transactions)
+	stats := make([]ruleStats, len(rules))
+	for i := range stats {
+		stats[i] = ruleStats{
+			beatenBy:      make(map[int]int),
+			examples:      make(map[int][]string),
+			ties:          make(map[int]bool),
+			disagreements: make(map[string]int),
+		}
+	}
+
+	for _, input := range inputs {
+		winner, best := -1, 0.0
+		var matched []int
+		confidences := make(map[int]float64)
+		for _, i := range index.candidates(input.description) {
+			if !valid[i] {
+				continue
+			}
+			evaluation := c.calibrate(rules[i].evaluateCompiled(input.subject, index.patterns[i]), index.keys[i], input.description)
+			if !evaluation.Matched {
+				continue
+			}
+			matched = append(matched, i)
+			confidences[i] = evaluation.Confidence
+			if evaluation.Confidence > best {
+				winner, best = i, evaluation.Confidence
+			}
+		}
+
+		for _, i := range matched {
+			stats[i].matched++
+			if input.history {
+				stats[i].historyMatched++
+			}
+			if i == winner {
+				stats[i].wins++
+				if input.category != "" && input.category != rules[i].Category {
+					stats[i].disagreements[input.category]++
+					stats[i].disagreeing = addExample(stats[i].disagreeing, input.description)
+				}
+				continue
+			}
+			stats[i].beatenBy[winner]++
+			if confidences[i] == best {
+				stats[i].ties[winner] = true
+			}
+			stats[i].examples[winner] = addExample(stats[i].examples[winner], input.description)
+		}
+	}
+
+	clashes := insideWords(rules, valid, literals, inputs)
+	for i, rule := range rules {
+		if !valid[i] || !rule.IsActive {
+			continue
+		}
+		findings = append(findings, rule.lintBroad(i, literals[i], clashes[i])...)
+		findings = append(findings, lintOverlaps(rules, i, stats[i])...)
+		if len(transactions) > 0 && stats[i].historyMatched == 0 {
+			findings = append(findings, LintFinding{
+				Kind:    LintUnused,
+				Rule:    i,
+				Other:   -1,
+				Message: fmt.Sprintf("never matched any of your %d transactions", len(transactions)),
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(a, b int) bool {
+		if findings[a].Rule != findings[b].Rule {
+			return findings[a].Rule < findings[b].Rule
+		}
+		return lintKindOrder[findings[a].Kind] < lintKindOrder[findings[b].Kind]
+	})
+	return findings
+}
+
+// lintInputs builds the transactions to try: each rule's keywords and the
+// words its pattern stands for, as the kind of transaction the rule is for,
+// followed by the user's own transactions
+func lintInputs(rules []CategorizationRule, valid []bool, literals [][]string, transactions []budget.Transaction) []lintInput {
+	var inputs []lintInput
+	type probe struct {
+		description string
+		amount      float64
+		transType   budget.TransactionType
+	}
+	seen := make(map[probe]bool)
+
+	for i, rule := range rules {
+		if !valid[i] || !rule.IsActive {
+			continue
+		}
+		types := []budget.TransactionType{budget.Expense, budget.Income}
+		if rule.TransactionType != "" {
+			types = []budget.TransactionType{rule.TransactionType}
+		}
+		amount := rule.MinAmount
+		if amount == 0 {
+			amount = rule.MaxAmount
+		}
+		for _, literal := range literals[i] {
+			for _, transType := range types {
+				if p := (probe{literal, amount, transType}); !seen[p] {
+					seen[p] = true
+					t := budget.Transaction{Description: literal, Amount: amount, Type: transType}
+					inputs = append(inputs, lintInput{subject: newSubject(t)})
+				}
+			}
+		}
+	}
+
+	for _, t := range transactions {
+		// Rules are written against what the bank says, not our edits
+		if t.OriginalDescription != "" {
+			t.Description = t.OriginalDescription
+		}
+		input := lintInput{subject: newSubject(t), history: true}
+		if learnable(t) && t.CategorySetByUser() {
+			input.category = t.Category
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// lintOverlaps reports the rule as shadowed when it matched something but
+// never won, and otherwise reports each rule of another category that took
+// some of its transactions
+func lintOverlaps(rules []CategorizationRule, i int, stats ruleStats) []LintFinding {
+	rule := rules[i]
+	if stats.matched > 0 && stats.wins == 0 {
+		winner := -1
+		for other, count := range stats.beatenBy {
+			if winner == -1 || count > stats.beatenBy[winner] || count == stats.beatenBy[winner] && other < winner {
+				winner = other
+			}
+		}
+		message := fmt.Sprintf("never wins: %s takes everything it matches", rules[winner].describe())
+		if rules[winner].Category == rule.Category {
+			message = fmt.Sprintf("redundant: %s already categorizes everything it matches", rules[winner].describe())
+		}
+		if stats.ties[winner] {
+			message += " (tied, but tried first)"
+		}
+		return []LintFinding{{Kind: LintShadowed, Rule: i, Other: winner, Message: message, Examples: stats.examples[winner]}}
+	}
+
+	var others []int
+	for other := range stats.beatenBy {
+		if rules[other].Category != rule.Category {
+			others = append(others, other)
+		}
+	}
+	sort.Ints(others)
+
+	var findings []LintFinding
+	for _, other := range others {
+		message := fmt.Sprintf("loses %d of its matches to %s", stats.beatenBy[other], rules[other].describe())
+		if stats.ties[other] {
+			message += " (tied, but tried first)"
+		}
+		findings = append(findings, LintFinding{
+			Kind:     LintConflict,
+			Rule:     i,
+			Other:    other,
+			Message:  message,
+			Examples: stats.examples[other],
+		})
+	}
+
+	if len(stats.disagreements) > 0 {
+		total, usual := 0, ""
+		for category, count := range stats.disagreements {
+			total += count
+			if usual == "" || count > stats.disagreements[usual] || count == stats.disagreements[usual] && category < usual {
+				usual = category
+			}
+		}
+		findings = append(findings, LintFinding{
+			Kind:     LintConflict,
+			Rule:     i,
+			Other:    -1,
+			Message:  fmt.Sprintf("wins %s you categorized differently, mostly as %s", plural(total, "transactions"), usual),
+			Examples: stats.disagreeing,
+		})
+	}
+	return findings
+}
+
+// addExample adds description to examples unless it is already there or
+// there are enough
+func addExample(examples []string, description string) []string {
+	if len(examples) >= maxLintExamples || slices.Contains(examples, description) {
+		return examples
+	}
+	return append(examples, description)
+}
+
+// plural is count followed by what is counted, singular when count is 1
+func plural(count int, things string) string {
+	if count == 1 {
+		things = strings.TrimSuffix(things, "s")
+	}
+	return fmt.Sprintf("%d %s", count, things)
+}
+
+// wordClash counts the inputs a rule's word turns up inside longer words in
+type wordClash struct {
+	count    int
+	examples []string
+}
+
+// insideWords finds, for each word of each rule, the inputs it appears in
+// in the middle of a word, like "gas" in "las vegas". All the words are
+// looked for in one pass over each input.
+func insideWords(rules []CategorizationRule, valid []bool, literals [][]string, inputs []lintInput) [][]wordClash {
+	clashes := make([][]wordClash, len(rules))
+	type owner struct{ rule, literal int }
+	var texts []string
+	var owners []owner
+	for i, rule := range rules {
+		clashes[i] = make([]wordClash, len(literals[i]))
+		if !valid[i] || !rule.IsActive {
+			continue
+		}
+		for j, literal := range literals[i] {
+			if first, _ := utf8.DecodeRuneInString(literal); len(literal) >= minLiteralLength && unicode.IsLetter(first) {
+				texts = append(texts, literal)
+				owners = append(owners, owner{i, j})
+			}
+		}
+	}
+
+	automaton := newAhoCorasick(texts)
+	// counted holds the last input each word was counted for, so an input
+	// counts once however often the word turns up in it
+	counted := make([]int, len(texts))
+	for id := range counted {
+		counted[id] = -1
+	}
+	for n, input := range inputs {
+		automaton.find(input.description, func(id, end int) {
+			start := end - len(texts[id])
+			if counted[id] == n || start == 0 {
+				return
+			}
+			if before, _ := utf8.DecodeLastRuneInString(input.description[:start]); !unicode.IsLetter(before) {
+				return
+			}
+			counted[id] = n
+			clash := &clashes[owners[id].rule][owners[id].literal]
+			clash.count++
+			clash.examples = addExample(clash.examples, input.description)
+		})
+	}
+	return clashes
+}
+
+// lintBroad looks for a pattern that matches anything and for words that
+// are short or turn up inside longer words
+func (rule CategorizationRule) lintBroad(i int, literals []string, clashes []wordClash) []LintFinding {
+	var findings []LintFinding
+	if rule.Pattern != "" {
+		if re, err := compilePattern(rule.Pattern); err == nil && re.MatchString("") {
+			findings = append(findings, LintFinding{Kind: LintBroad, Rule: i, Other: -1, Message: "the pattern matches every description"})
+		}
+	}
+
+	for j, literal := range literals {
+		if len(literal) < minLiteralLength {
+			findings = append(findings, LintFinding{
+				Kind:    LintBroad,
+				Rule:    i,
+				Other:   -1,
+				Message: fmt.Sprintf("%q is short enough to turn up in unrelated descriptions", literal),
+			})
+			continue
+		}
+
+		if clash := clashes[j]; clash.count > 0 {
+			findings = append(findings, LintFinding{
+				Kind:     LintBroad,
+				Rule:     i,
+				Other:    -1,
+				Message:  fmt.Sprintf("%q matches inside other words in %s", literal, plural(clash.count, "descriptions")),
+				Examples: clash.examples,
+			})
+		}
+	}
+	return findings
+}
+
+// literals lists the keywords and the words the pattern stands for
+func (rule CategorizationRule) literals() []string {
+	var literals []string
+	seen := make(map[string]bool)
+	for _, literal := range append(patternLiterals(rule.Pattern), rule.Keywords...) {
+		literal = strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(literal, anyText, " "))), " ")
+		if literal != "" && !seen[literal] {
+			seen[literal] = true
+			literals = append(literals, literal)
+		}
+	}
+	return literals
+}
+
+// patternLiterals lists the words a pattern stands for when it is plain text
+// or alternatives of it, like ".*Uber|Lyft|Taxi.*", or nil when it is more
+// complicated than that. A .* in them is written as anyText.
+func patternLiterals(pattern string) []string {
+	if pattern == "" {
+		return nil
+	}
+	re, err := syntax.Parse(strings.ToLower(pattern), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	literals, ok := expand(re.Simplify())
+	if !ok {
+		return nil
+	}
+	return literals
+}
+
+// anyText stands for a .* in the strings expand returns
+const anyText = "\x00"
+
+// expand lists every string re matches, if there are only a few
+func expand(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+	case syntax.OpStar:
+		if re.Sub[0].Op == syntax.OpAnyCharNotNL || re.Sub[0].Op == syntax.OpAnyChar {
+			return []string{anyText}, true
+		}
+	case syntax.OpCapture:
+		return expand(re.Sub[0])
+	case syntax.OpQuest:
+		sub, ok := expand(re.Sub[0])
+		return append([]string{""}, sub...), ok && len(sub) < maxLiterals
+	case syntax.OpCharClass:
+		var result []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(result) == maxLiterals {
+					return nil, false
+				}
+				result = append(result, string(r))
+			}
+		}
+		return result, true
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, sub := range re.Sub {
+			parts, ok := expand(sub)
+			if !ok || len(result)*len(parts) > maxLiterals {
+				return nil, false
+			}
+			var next []string
+			for _, prefix := range result {
+				for _, part := range parts {
+					next = append(next, prefix+part)
+				}
+			}
+			result = next
+		}
+		return result, true
+	case syntax.OpAlternate:
+		var result []string
+		for _, sub := range re.Sub {
+			parts, ok := expand(sub)
+			if !ok || len(result)+len(parts) > maxLiterals {
+				return nil, false
+			}
+			result = append(result, parts...)
+		}
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,61 @@
+package categorizer
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestLintRules(t *testing.T) {
+	rule := func(priority int, category string, keywords ...string) CategorizationRule {
+		return CategorizationRule{Category: category, Keywords: keywords, Priority: priority, IsActive: true}
+	}
+	spent := func(description, category string) budget.Transaction {
+		return budget.Transaction{Description: description, Category: category, Amount: 10, Type: budget.Expense}
+	}
+
+	tests := []struct {
+		name         string
+		rules        []CategorizationRule
+		transactions []budget.Transaction
+		want         []string
+	}{
+		{"clean", []CategorizationRule{rule(1, "Coffee", "starbucks")}, []budget.Transaction{spent("STARBUCKS 12", "Coffee")}, nil},
+		{"invalid pattern", []CategorizationRule{{Pattern: "(", Category: "Broken", Priority: 1, IsActive: true}}, nil, []string{"0 invalid"}},
+		{"short keyword", []CategorizationRule{rule(1, "Misc", "ab")}, nil, []string{"0 too broad"}},
+		{"inside a word", []CategorizationRule{rule(1, "Fuel", "gas")}, []budget.Transaction{spent("Las Vegas Hotel", ""), spent("Shell Gas", "Fuel")}, []string{"0 too broad"}},
+		{"inside an accented word", []CategorizationRule{rule(1, "Water", "vian")}, []budget.Transaction{spent("Évian", "Water")}, []string{"0 too broad"}},
+		{"separate word", []CategorizationRule{rule(1, "Water", "vian")}, []budget.Transaction{spent("Eau vian", "Water")}, nil},
+		{"shadowed", []CategorizationRule{rule(10, "Coffee", "coffee"), rule(1, "Cafe", "coffee")}, nil, []string{"1 shadowed"}},
+		{"unused", []CategorizationRule{rule(1, "Streaming", "netflix")}, []budget.Transaction{spent("Spotify", "Music")}, []string{"0 unused"}},
+		{"categorized differently", []CategorizationRule{rule(1, "Shopping", "amazon")}, []budget.Transaction{spent("AMAZON PRIME", "Subscriptions")}, []string{"0 conflict"}},
+		{"inactive rule ignored", []CategorizationRule{{Category: "Misc", Keywords: []string{"ab"}, Priority: 1}}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range testCategorizer(tt.rules).LintRules(tt.transactions) {
+				got = append(got, fmt.Sprintf("%d %s", finding.Rule, finding.Kind))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("LintRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintRulesUsesIndex(t *testing.T) {
+	rules := syntheticRules(200)
+	transactions := syntheticTransactions(500, rules)
+	c := testCategorizer(rules)
+	indexed := c.LintRules(transactions)
+	c.index = unindexed(c.rules)
+	if every := c.LintRules(transactions); !slices.EqualFunc(indexed, every, func(a, b LintFinding) bool {
+		return a.Kind == b.Kind && a.Rule == b.Rule && a.Other == b.Other && a.Message == b.Message
+	}) {
+		t.Errorf("indexed lint found %d problems, trying every rule found %d", len(indexed), len(every))
+	}
+}
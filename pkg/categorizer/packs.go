@@ -0,0 +1,252 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RulePack is a named, versioned set of rules to share, such as the rules
+// for a region's merchants. Its file is a rules file with a name and
+// version; its RemovedDefaults, ReplaceDefaults and Packs are ignored.
+type RulePack struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	CategoryConfig
+}
+
+// PackInfo records a pack installed from a file
+type PackInfo struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Description string    `json:"description,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ConflictPolicy says what an import does when the pack is already
+// installed, or one of its rules matches the same way as one already there
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves what is installed alone
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces it: an installed pack of the same name is
+	// updated and a clashing rule is replaced by the pack's
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename installs the pack under a new name next to the one
+	// installed, keeping any clashing rule
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// ParseConflictPolicy reads a policy as written on the command line
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	policy := ConflictPolicy(strings.ToLower(strings.TrimSpace(value)))
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q: use %s, %s or %s", value, ConflictSkip, ConflictOverwrite, ConflictRename)
+}
+
+// Validate checks the pack and each of its rules
+func (p RulePack) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return errors.New("a rule pack needs a name")
+	case strings.TrimSpace(p.Version) == "":
+		return errors.New("a rule pack needs a version")
+	case len(p.Rules) == 0:
+		return errors.New("a rule pack needs at least one rule")
+	}
+	for i, rule := range p.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i+1, rule.Category, err)
+		}
+	}
+	return nil
+}
+
+// ReadRulePack reads and checks a pack file
+func ReadRulePack(path string) (RulePack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RulePack{}, err
+	}
+	var pack RulePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return RulePack{}, fmt.Errorf("%s is not a rule pack: %w", path, err)
+	}
+	return pack, pack.Validate()
+}
+
+// Write saves the pack to a file
+func (p RulePack) Write(path string) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ExportPack makes a pack of the rules for which selected is true. Where
+// the rules came from is left out, so they belong to the new pack.
+func (c *Categorizer) ExportPack(name, version, description string, selected func(CategorizationRule) bool) RulePack {
+	pack := RulePack{Name: name, Version: version, Description: description}
+	for _, rule := range c.Rules() {
+		if selected(rule) {
+			rule.Pack = ""
+			pack.Rules = append(pack.Rules, rule)
+		}
+	}
+	return pack
+}
+
+// Packs returns the installed packs
+func (c *Categorizer) Packs() []PackInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.packs)
+}
+
+// PackImport is what importing a pack did
+type PackImport struct {
+	// Installed is false when the pack was skipped as already installed
+	Installed bool
+	// Name is the pack's name as installed, which a rename changes
+	Name string
+	// Replaced is the version of the pack the import updated, if any
+	Replaced string
+	Added    int
+	// Overwritten counts the rules the pack's replaced and Skipped the
+	// pack's rules left out because of a clash
+	Overwritten int
+	Skipped     int
+}
+
+// errPackSkipped stops an import that would skip the whole pack before
+// anything is saved
+var errPackSkipped = errors.New("rule pack already installed")
+
+// ImportPack installs a pack, recording its rules as coming from it.
+// source is where it was read from, for the record.
+func (c *Categorizer) ImportPack(pack RulePack, source string, policy ConflictPolicy) (PackImport, error) {
+	if err := pack.Validate(); err != nil {
+		return PackImport{}, err
+	}
+
+	result := PackImport{Name: pack.Name}
+	err := c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		installed := slices.IndexFunc(c.packs, func(info PackInfo) bool { return info.Name == pack.Name })
+		switch {
+		case installed < 0:
+		case policy == ConflictSkip:
+			return nil, errPackSkipped
+		case policy == ConflictOverwrite:
+			result.Replaced = c.packs[installed].Version
+			rules = slices.DeleteFunc(rules, func(rule CategorizationRule) bool { return rule.Pack == pack.Name })
+		case policy == ConflictRename:
+			result.Name = c.unusedPackName(pack.Name)
+		}
+
+		for _, rule := range pack.Rules {
+			rule.Pack = result.Name
+			clash := slices.IndexFunc(rules, func(other CategorizationRule) bool {
+				// A renamed copy goes next to the installed pack's rules
+				if policy == ConflictRename && other.Pack == pack.Name {
+					return false
+				}
+				return other.sameMatch(rule)
+			})
+			switch {
+			case clash < 0:
+				result.Added++
+			case policy == ConflictOverwrite:
+				rules = slices.Delete(rules, clash, clash+1)
+				result.Overwritten++
+			default:
+				result.Skipped++
+				continue
+			}
+			rules = append(rules, rule)
+		}
+		sortRules(rules)
+
+		// Recorded only if the rules are saved, as manageRules puts the
+		// packs back otherwise
+		info := PackInfo{Name: result.Name, Version: pack.Version, Description: pack.Description, Source: source, InstalledAt: time.Now()}
+		c.packs = slices.DeleteFunc(c.packs, func(other PackInfo) bool { return other.Name == info.Name })
+		c.packs = append(c.packs, info)
+		result.Installed = true
+		return rules, nil
+	})
+	if errors.Is(err, errPackSkipped) {
+		result.Skipped = len(pack.Rules)
+		return result, nil
+	}
+	if err != nil {
+		return PackImport{}, err
+	}
+	return result, nil
+}
+
+// RemovePack uninstalls a pack and every rule that came from it, returning
+// how many rules were removed
+func (c *Categorizer) RemovePack(name string) (int, error) {
+	removed := 0
+	err := c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		installed := slices.IndexFunc(c.packs, func(info PackInfo) bool { return info.Name == name })
+		if installed < 0 {
+			return nil, fmt.Errorf("no rule pack named %q is installed", name)
+		}
+		c.packs = slices.Delete(c.packs, installed, installed+1)
+		before := len(rules)
+		rules = slices.DeleteFunc(rules, func(rule CategorizationRule) bool { return rule.Pack == name })
+		removed = before - len(rules)
+		return rules, nil
+	})
+	return removed, err
+}
+
+// unusedPackName numbers name, like "Regional (2)", so it differs from the
+// installed packs; the caller holds the lock
+func (c *Categorizer) unusedPackName(name string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !slices.ContainsFunc(c.packs, func(info PackInfo) bool { return info.Name == candidate }) {
+			return candidate
+		}
+	}
+}
+
+// sameMatch reports whether two rules match the same transactions, whatever
+// category and priority they give them
+func (rule CategorizationRule) sameMatch(other CategorizationRule) bool {
+	lower := func(keywords []string) []string {
+		lowered := make([]string, len(keywords))
+		for i, keyword := range keywords {
+			lowered[i] = strings.ToLower(keyword)
+		}
+		slices.Sort(lowered)
+		return lowered
+	}
+	conditions := func(c *Condition) string {
+		if c == nil {
+			return ""
+		}
+		return c.String()
+	}
+	return strings.EqualFold(rule.Pattern, other.Pattern) &&
+		slices.Equal(lower(rule.Keywords), lower(other.Keywords)) &&
+		rule.TransactionType == other.TransactionType &&
+		rule.MinAmount == other.MinAmount && rule.MaxAmount == other.MaxAmount &&
+		conditions(rule.Conditions) == conditions(other.Conditions)
+}
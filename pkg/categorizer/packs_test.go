@@ -0,0 +1,122 @@
+package categorizer
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestImportPack(t *testing.T) {
+	userRule := CategorizationRule{Pattern: ".*tesco.*", Category: "Groceries", Priority: 90, IsActive: true}
+	pack := func(version string) RulePack {
+		return RulePack{Name: "UK", Version: version, CategoryConfig: CategoryConfig{Rules: []CategorizationRule{
+			{Pattern: ".*tesco.*", Category: "Supermarkets", Priority: 80, IsActive: true},
+			{Pattern: ".*greggs.*", Category: "Dining", Priority: 80, IsActive: true},
+		}}}
+	}
+
+	tests := []struct {
+		name      string
+		installed bool
+		policy    ConflictPolicy
+		want      PackImport
+		wantPacks []string
+		// wantRules counts the rules from each pack, "" being the user's
+		wantRules map[string]int
+	}{
+		{"new, skip", false, ConflictSkip,
+			PackImport{Installed: true, Name: "UK", Added: 1, Skipped: 1}, []string{"UK"}, map[string]int{"": 1, "UK": 1}},
+		{"new, overwrite", false, ConflictOverwrite,
+			PackImport{Installed: true, Name: "UK", Added: 1, Overwritten: 1}, []string{"UK"}, map[string]int{"UK": 2}},
+		{"new, rename", false, ConflictRename,
+			PackImport{Installed: true, Name: "UK", Added: 1, Skipped: 1}, []string{"UK"}, map[string]int{"": 1, "UK": 1}},
+		{"installed, skip", true, ConflictSkip,
+			PackImport{Name: "UK", Skipped: 2}, []string{"UK"}, map[string]int{"": 1, "UK": 1}},
+		{"installed, overwrite", true, ConflictOverwrite,
+			PackImport{Installed: true, Name: "UK", Replaced: "1.0", Added: 1, Overwritten: 1}, []string{"UK"}, map[string]int{"UK": 2}},
+		{"installed, rename", true, ConflictRename,
+			PackImport{Installed: true, Name: "UK (2)", Added: 1, Skipped: 1}, []string{"UK", "UK (2)"}, map[string]int{"": 1, "UK": 1, "UK (2)": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			c := testCategorizer([]CategorizationRule{userRule})
+			if tt.installed {
+				if _, err := c.ImportPack(pack("1.0"), "uk.json", ConflictSkip); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := c.ImportPack(pack("1.1"), "uk.json", tt.policy)
+			if err != nil {
+				t.Fatalf("ImportPack() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ImportPack() = %+v, want %+v", got, tt.want)
+			}
+
+			var packs []string
+			for _, info := range c.Packs() {
+				packs = append(packs, info.Name)
+			}
+			if !slices.Equal(packs, tt.wantPacks) {
+				t.Errorf("packs = %v, want %v", packs, tt.wantPacks)
+			}
+			rules := make(map[string]int)
+			for _, rule := range c.Rules() {
+				rules[rule.Pack]++
+			}
+			for name, count := range tt.wantRules {
+				if rules[name] != count {
+					t.Errorf("%d rules from %q, want %d", rules[name], name, count)
+				}
+			}
+			if len(rules) != len(tt.wantRules) {
+				t.Errorf("rules come from %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestImportPackSaveFails(t *testing.T) {
+	// A home that doesn't exist can't hold the rules file
+	t.Setenv("HOME", filepath.Join(t.TempDir(), "missing"))
+	c := testCategorizer([]CategorizationRule{{Pattern: ".*tesco.*", Category: "Groceries", Priority: 90, IsActive: true}})
+	pack := RulePack{Name: "UK", Version: "1.0", CategoryConfig: CategoryConfig{Rules: []CategorizationRule{
+		{Pattern: ".*greggs.*", Category: "Dining", Priority: 80, IsActive: true},
+	}}}
+
+	if _, err := c.ImportPack(pack, "uk.json", ConflictSkip); err == nil {
+		t.Fatal("ImportPack() saved into a missing directory")
+	}
+	if packs := c.Packs(); len(packs) != 0 {
+		t.Errorf("packs = %v after a failed save, want none", packs)
+	}
+	if rules := c.Rules(); len(rules) != 1 {
+		t.Errorf("%d rules after a failed save, want 1", len(rules))
+	}
+}
+
+func TestRemovePack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := testCategorizer(nil)
+	pack := RulePack{Name: "UK", Version: "1.0", CategoryConfig: CategoryConfig{Rules: []CategorizationRule{
+		{Pattern: ".*greggs.*", Category: "Dining", Priority: 80, IsActive: true},
+		{Pattern: ".*tesco.*", Category: "Groceries", Priority: 80, IsActive: true},
+	}}}
+	if _, err := c.ImportPack(pack, "uk.json", ConflictSkip); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.RemovePack("UK")
+	if err != nil || removed != 2 {
+		t.Fatalf("RemovePack() = %d, %v, want 2 removed", removed, err)
+	}
+	if len(c.Packs()) != 0 || len(c.Rules()) != 0 {
+		t.Errorf("left %v and %d rules", c.Packs(), len(c.Rules()))
+	}
+	if _, err := c.RemovePack("UK"); err == nil {
+		t.Error("removed a pack that isn't installed")
+	}
+}
@@ -0,0 +1,138 @@
+package categorizer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// ManualSource is the source of transactions that were added by hand rather
+// than imported
+const ManualSource = "manual"
+
+// RecategorizeFilter picks the stored transactions to run the rules over
+// again. Zero values match everything.
+type RecategorizeFilter struct {
+	From, To time.Time
+	// Source is the format a transaction was imported with, or ManualSource
+	Source string
+	// Category is the transaction's current category
+	Category string
+	// ProtectManual leaves categories the user chose alone
+	ProtectManual bool
+}
+
+// Matches reports whether t is selected by the filter
+func (f RecategorizeFilter) Matches(t budget.Transaction) bool {
+	if !f.From.IsZero() && t.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && t.Date.After(f.To.AddDate(0, 0, 1).Add(-time.Nanosecond)) {
+		return false
+	}
+	if f.Source != "" && !strings.EqualFold(f.Source, source(t)) {
+		return false
+	}
+	if f.Category != "" && !strings.EqualFold(f.Category, t.Category) {
+		return false
+	}
+	if f.ProtectManual && t.CategorySetByUser() {
+		return false
+	}
+	return true
+}
+
+func source(t budget.Transaction) string {
+	if !t.IsImported {
+		return ManualSource
+	}
+	return t.ImportSource
+}
+
+// CategoryChange is a new category proposed for a stored transaction
+type CategoryChange struct {
+	Index       int // into the transactions the plan was made for
+	ID          string
+	Date        time.Time
+	Description string
+	OldCategory string
+	NewCategory string
+	Confidence  float64
+	// Rule and MatchedBy say which rule chose the new category and how, and
+	// Actions are that rule's
+	Rule      string
+	MatchedBy string
+	Actions   RuleActions
+}
+
+// PlanRecategorize runs the current rules and what has been learned over
+// the transactions the filter selects, and returns the categories that
+// would change. Nothing is changed, so the plan works as a dry run.
+// Reconciled transactions and transfers are left out, and a category is
+// never replaced by Uncategorized.
+func (c *Categorizer) PlanRecategorize(transactions []budget.Transaction, filter RecategorizeFilter) []CategoryChange {
+	var changes []CategoryChange
+	for i, t := range transactions {
+		if t.IsLocked() || t.IsTransfer || !filter.Matches(t) {
+			continue
+		}
+
+		// Rules are written against what the bank says, not our edits
+		description := t.Description
+		if t.OriginalDescription != "" {
+			t.Description = t.OriginalDescription
+		}
+		result := c.Categorize(t)
+		if result.Category == t.Category || result.Category == "Uncategorized" {
+			continue
+		}
+
+		changes = append(changes, CategoryChange{
+			Index:       i,
+			ID:          t.ID,
+			Date:        t.Date,
+			Description: description,
+			OldCategory: t.Category,
+			NewCategory: result.Category,
+			Confidence:  result.Confidence,
+			Rule:        result.Rule,
+			MatchedBy:   result.MatchedBy,
+			Actions:     result.Actions,
+		})
+	}
+	return changes
+}
+
+// ApplyRecategorize makes the planned changes to b, along with the actions
+// of the rules that chose them, and learns from them. Each change counts
+// as a kept suggestion in its rule's stats. It returns how many
+// transactions changed; ones reconciled since the plan was made are skipped.
+func (c *Categorizer) ApplyRecategorize(b *budget.Budget, changes []CategoryChange) int {
+	count := 0
+	var applied []budget.Transaction
+	for _, change := range changes {
+		before := b.FindTransaction(change.ID)
+		if before == nil {
+			continue
+		}
+		old := *before
+		err := b.UpdateTransaction(change.ID, func(t *budget.Transaction) {
+			t.Category = change.NewCategory
+			t.Confidence = change.Confidence
+			t.ManualCategory = false
+			t.Splits = nil
+			t.CategorizedBy, t.MatchedBy = change.Rule, change.MatchedBy
+			change.Actions.Apply(t)
+		})
+		if err != nil {
+			continue
+		}
+		after := *b.FindTransaction(change.ID)
+		c.Recategorize(old, after)
+		applied = append(applied, after)
+		count++
+	}
+	c.RecordOutcomes(applied)
+	return count
+}
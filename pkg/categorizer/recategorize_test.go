@@ -0,0 +1,51 @@
+package categorizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestRecategorizeTransfers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rule := func(category string, actions *RuleActions) CategorizationRule {
+		return CategorizationRule{Keywords: []string{"xfer"}, Category: category, Priority: 1, IsActive: true, Actions: actions}
+	}
+
+	tests := []struct {
+		name         string
+		rule         CategorizationRule
+		category     string
+		edit         func(t *budget.Transaction)
+		wantChange   bool
+		wantTransfer bool
+	}{
+		{"transfer action", rule("Transfers", &RuleActions{Transfer: true}), "Uncategorized", nil, true, true},
+		{"category alone", rule("Transfers", nil), "Uncategorized", nil, true, false},
+		{"transfer left alone", rule("Savings", nil), "Transfers", func(t *budget.Transaction) { t.IsTransfer = true }, false, true},
+		{"manual category protected", rule("Savings", nil), "Rent", func(t *budget.Transaction) { t.ManualCategory = true }, false, false},
+		{"reconciled left alone", rule("Savings", nil), "Rent", func(t *budget.Transaction) { t.Status = budget.Reconciled }, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := budget.Transaction{ID: "a", Description: "XFER TO SAVINGS", Category: tt.category, Amount: 50,
+				Type: budget.Expense, Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), IsImported: true}
+			if tt.edit != nil {
+				tt.edit(&transaction)
+			}
+			c := testCategorizer([]CategorizationRule{tt.rule})
+			b := &budget.Budget{Transactions: []budget.Transaction{transaction}}
+
+			changes := c.PlanRecategorize(b.Transactions, RecategorizeFilter{ProtectManual: true})
+			if (len(changes) == 1) != tt.wantChange {
+				t.Fatalf("planned %d changes, want a change: %v", len(changes), tt.wantChange)
+			}
+			c.ApplyRecategorize(b, changes)
+			if got := b.Transactions[0]; got.IsTransfer != tt.wantTransfer {
+				t.Errorf("transfer = %v, want %v (category %s)", got.IsTransfer, tt.wantTransfer, got.Category)
+			}
+		})
+	}
+}
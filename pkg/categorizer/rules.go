@@ -0,0 +1,198 @@
+package categorizer
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// defaultRules are the built-in rules, for telling them apart from the
+// user's own
+var defaultRules = new(Categorizer).getDefaultRules()
+
+// IsDefaultRule reports whether rule is one of the built-in rules
+func IsDefaultRule(rule CategorizationRule) bool {
+	for _, defaultRule := range defaultRules {
+		if rule.Pattern == defaultRule.Pattern && rule.Category == defaultRule.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePattern checks that a rule's pattern is a valid regular expression
+// as it will be matched, against a lowercased description
+func ValidatePattern(pattern string) error {
+	if _, err := compilePattern(pattern); err != nil {
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%s in %q", syntaxErr.Code, syntaxErr.Expr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Validate checks that the rule can be saved
+func (rule CategorizationRule) Validate() error {
+	switch {
+	case strings.TrimSpace(rule.Category) == "":
+		return errors.New("a rule needs a category")
+	case rule.Pattern == "" && len(rule.Keywords) == 0 && rule.Conditions == nil:
+		return errors.New("a rule needs a pattern, keywords or conditions")
+	case rule.Priority < 0 || rule.Priority > 100:
+		return errors.New("priority must be between 0 and 100")
+	case rule.MinAmount < 0 || rule.MaxAmount < 0:
+		return errors.New("amount bounds can't be negative")
+	case rule.MaxAmount > 0 && rule.MinAmount > rule.MaxAmount:
+		return errors.New("the minimum amount is above the maximum")
+	case rule.TransactionType != "" && rule.TransactionType != budget.Income && rule.TransactionType != budget.Expense:
+		return fmt.Errorf("type must be %s or %s", budget.Income, budget.Expense)
+	}
+	if rule.Pattern != "" {
+		if err := ValidatePattern(rule.Pattern); err != nil {
+			return err
+		}
+	}
+	if rule.Conditions != nil {
+		if err := rule.Conditions.Validate(); err != nil {
+			return err
+		}
+	}
+	if rule.Actions != nil {
+		return rule.Actions.Validate()
+	}
+	return nil
+}
+
+// describe names a rule in findings and explanations
+func (rule CategorizationRule) describe() string {
+	switch {
+	case rule.Pattern != "":
+		return fmt.Sprintf("the %s rule %q", rule.Category, rule.Pattern)
+	case len(rule.Keywords) > 0:
+		return fmt.Sprintf("the %s rule for %q", rule.Category, strings.Join(rule.Keywords, ", "))
+	default:
+		return fmt.Sprintf("the %s rule when %s", rule.Category, rule.Conditions)
+	}
+}
+
+// Rules returns every rule, defaults included, in the order they are tried
+func (c *Categorizer) Rules() []CategorizationRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.rules)
+}
+
+// manageRules changes the rules and saves them, along with the changes to
+// default rules. edit may also change the packs. If the rules can't be
+// saved, nothing changes.
+func (c *Categorizer) manageRules(edit func(rules []CategorizationRule) ([]CategorizationRule, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	oldRules, oldIndex, oldPacks := c.rules, c.index, slices.Clone(c.packs)
+	rules, err := edit(slices.Clone(c.rules))
+	if err != nil {
+		c.packs = oldPacks
+		return err
+	}
+	c.rules = rules
+	c.index = newRuleIndex(c.rules)
+	if err := c.saveCustomRules(); err != nil {
+		c.rules, c.index, c.packs = oldRules, oldIndex, oldPacks
+		return err
+	}
+	return nil
+}
+
+// SaveRule replaces the rule at index i, or adds it when i is -1, and
+// returns where it ends up in the priority order
+func (c *Categorizer) SaveRule(i int, rule CategorizationRule) (int, error) {
+	if err := rule.Validate(); err != nil {
+		return i, err
+	}
+
+	position := i
+	err := c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		if i >= len(rules) {
+			return nil, fmt.Errorf("no rule %d", i)
+		}
+		if i >= 0 && rules[i].Priority == rule.Priority {
+			rules[i] = rule
+			return rules, nil
+		}
+		if i >= 0 {
+			rules = slices.Delete(rules, i, i+1)
+		}
+
+		// Keep the rules in priority order, after others of the same priority
+		position = len(rules)
+		for j, other := range rules {
+			if other.Priority < rule.Priority {
+				position = j
+				break
+			}
+		}
+		return slices.Insert(rules, position, rule), nil
+	})
+	return position, err
+}
+
+// DeleteRule removes the rule at index i
+func (c *Categorizer) DeleteRule(i int) error {
+	return c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		if i < 0 || i >= len(rules) {
+			return nil, fmt.Errorf("no rule %d", i)
+		}
+		return slices.Delete(rules, i, i+1), nil
+	})
+}
+
+// ToggleRule turns the rule at index i on or off
+func (c *Categorizer) ToggleRule(i int) error {
+	return c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		if i < 0 || i >= len(rules) {
+			return nil, fmt.Errorf("no rule %d", i)
+		}
+		rules[i].IsActive = !rules[i].IsActive
+		return rules, nil
+	})
+}
+
+// MoveRule moves the rule at index i one place up (delta -1) or down
+// (delta 1), past its neighbour. A rule of another priority than its
+// neighbour takes the priority next to the neighbour's, or the neighbour's
+// own when the rule beyond leaves no room, so it stays tried before or
+// after it. It returns the rule's new index.
+func (c *Categorizer) MoveRule(i, delta int) (int, error) {
+	j := i + delta
+	err := c.manageRules(func(rules []CategorizationRule) ([]CategorizationRule, error) {
+		if i < 0 || i >= len(rules) || j < 0 || j >= len(rules) {
+			return nil, errors.New("can't move the rule any further")
+		}
+		if neighbour := rules[j].Priority; rules[i].Priority != neighbour {
+			beyond := j + delta
+			switch {
+			case delta < 0 && beyond >= 0:
+				rules[i].Priority = min(neighbour+1, rules[beyond].Priority)
+			case delta < 0:
+				rules[i].Priority = min(neighbour+1, 100)
+			case beyond < len(rules):
+				rules[i].Priority = max(neighbour-1, rules[beyond].Priority)
+			default:
+				rules[i].Priority = max(neighbour-1, 0)
+			}
+		}
+		rules[i], rules[j] = rules[j], rules[i]
+		return rules, nil
+	})
+	if err != nil {
+		return i, err
+	}
+	return j, nil
+}
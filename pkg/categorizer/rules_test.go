@@ -0,0 +1,242 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// ruleNames lists the rules' categories in the order they are tried
+func ruleNames(rules []CategorizationRule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Category)
+	}
+	return names
+}
+
+func managedRules() []CategorizationRule {
+	return []CategorizationRule{
+		{Pattern: "a", Category: "A", Priority: 100, IsActive: true},
+		{Pattern: "b", Category: "B", Priority: 90, IsActive: true},
+		{Pattern: "c", Category: "C", Priority: 50, IsActive: true},
+		{Pattern: "d", Category: "D", Priority: 50, IsActive: true},
+		{Pattern: "e", Category: "E", Priority: 10, IsActive: true},
+	}
+}
+
+func TestSaveRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		i            int
+		rule         CategorizationRule
+		wantPosition int
+		wantNames    []string
+		wantErr      bool
+	}{
+		{"add after the same priority", -1, CategorizationRule{Pattern: "x", Category: "X", Priority: 50}, 4, []string{"A", "B", "C", "D", "X", "E"}, false},
+		{"add between priorities", -1, CategorizationRule{Pattern: "x", Category: "X", Priority: 70}, 2, []string{"A", "B", "X", "C", "D", "E"}, false},
+		{"edit in place", 2, CategorizationRule{Pattern: "x", Category: "X", Priority: 50}, 2, []string{"A", "B", "X", "D", "E"}, false},
+		{"raise the priority", 3, CategorizationRule{Pattern: "d", Category: "D", Priority: 95}, 1, []string{"A", "D", "B", "C", "E"}, false},
+		{"no category", 2, CategorizationRule{Pattern: "x", Priority: 50}, 2, []string{"A", "B", "C", "D", "E"}, true},
+		{"no such rule", 5, CategorizationRule{Pattern: "x", Category: "X", Priority: 50}, 5, []string{"A", "B", "C", "D", "E"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			c := testCategorizer(managedRules())
+			position, err := c.SaveRule(tt.i, tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SaveRule() error = %v, want error %v", err, tt.wantErr)
+			}
+			if position != tt.wantPosition {
+				t.Errorf("SaveRule() = %d, want %d", position, tt.wantPosition)
+			}
+			if got := ruleNames(c.Rules()); !slices.Equal(got, tt.wantNames) {
+				t.Errorf("rules = %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestMoveRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		i, delta     int
+		wantNames    []string
+		wantPriority int
+		wantErr      bool
+	}{
+		{"up past a higher priority", 2, -1, []string{"A", "C", "B", "D", "E"}, 91, false},
+		{"up to the top", 1, -1, []string{"B", "A", "C", "D", "E"}, 100, false},
+		{"up past the same priority", 3, -1, []string{"A", "B", "D", "C", "E"}, 50, false},
+		{"down past a lower priority", 3, 1, []string{"A", "B", "C", "E", "D"}, 9, false},
+		{"down past the same priority", 2, 1, []string{"A", "B", "D", "C", "E"}, 50, false},
+		{"past the top", 0, -1, []string{"A", "B", "C", "D", "E"}, 100, true},
+		{"past the bottom", 4, 1, []string{"A", "B", "C", "D", "E"}, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			c := testCategorizer(managedRules())
+			moved := c.Rules()[tt.i].Category
+			j, err := c.MoveRule(tt.i, tt.delta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MoveRule() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			rules := c.Rules()
+			if got := ruleNames(rules); !slices.Equal(got, tt.wantNames) {
+				t.Errorf("rules = %v, want %v", got, tt.wantNames)
+			}
+			if rules[j].Category != moved || rules[j].Priority != tt.wantPriority {
+				t.Errorf("rule %d = %s at priority %d, want %s at %d", j, rules[j].Category, rules[j].Priority, moved, tt.wantPriority)
+			}
+			// Only the moved rule's priority changes
+			for _, rule := range managedRules() {
+				i := slices.IndexFunc(rules, func(other CategorizationRule) bool { return other.Pattern == rule.Pattern })
+				if rule.Category != moved && rules[i].Priority != rule.Priority {
+					t.Errorf("%s priority = %d, want %d", rule.Category, rules[i].Priority, rule.Priority)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteAndToggleRule(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := testCategorizer(managedRules())
+
+	if err := c.ToggleRule(1); err != nil {
+		t.Fatalf("ToggleRule() error = %v", err)
+	}
+	if c.Rules()[1].IsActive {
+		t.Error("ToggleRule() left the rule on")
+	}
+	if result := c.Categorize(budget.Transaction{Description: "b", Amount: 10, Type: budget.Expense}); result.Category == "B" {
+		t.Error("a rule turned off still categorizes")
+	}
+	if err := c.ToggleRule(1); err != nil || !c.Rules()[1].IsActive {
+		t.Errorf("ToggleRule() again = %v, want the rule back on", err)
+	}
+
+	if err := c.DeleteRule(2); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	if got, want := ruleNames(c.Rules()), []string{"A", "B", "D", "E"}; !slices.Equal(got, want) {
+		t.Errorf("rules = %v, want %v", got, want)
+	}
+
+	for _, i := range []int{-1, 4} {
+		if err := c.DeleteRule(i); err == nil {
+			t.Errorf("DeleteRule(%d) removed a rule that doesn't exist", i)
+		}
+		if err := c.ToggleRule(i); err == nil {
+			t.Errorf("ToggleRule(%d) changed a rule that doesn't exist", i)
+		}
+	}
+}
+
+// readRulesFile reads the rules file as saved
+func readRulesFile(t *testing.T) CategoryConfig {
+	t.Helper()
+	data, err := os.ReadFile(rulesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config CategoryConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestRulesFileKeepsDefaultsOut(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := NewCategorizer()
+	userRule := CategorizationRule{Pattern: ".*tesco.*", Category: "Groceries", Priority: 90, IsActive: true}
+	if _, err := c.SaveRule(-1, userRule); err != nil {
+		t.Fatal(err)
+	}
+	if config := readRulesFile(t); len(config.Rules) != 1 || len(config.RemovedDefaults) != 0 {
+		t.Fatalf("rules file holds %d rules and %d removed defaults, want only the new rule", len(config.Rules), len(config.RemovedDefaults))
+	}
+
+	// Netflix and Spotify share a priority, so swapping them has to save
+	// one to keep the order
+	index := func(pattern string) int {
+		return slices.IndexFunc(c.Rules(), func(rule CategorizationRule) bool { return rule.Pattern == pattern })
+	}
+	if _, err := c.MoveRule(index(".*Netflix.*"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ToggleRule(index(".*Amazon.*")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteRule(index(".*Payment.*")); err != nil {
+		t.Fatal(err)
+	}
+	rules := c.Rules()
+
+	// Besides the changed rules, only defaults that keep their place after
+	// them among rules of the same priority are saved
+	config := readRulesFile(t)
+	changed := map[string]bool{".*tesco.*": true, ".*Netflix.*": true, ".*Amazon.*": true}
+	priorities := make(map[int]bool)
+	for _, rule := range config.Rules {
+		if changed[rule.Pattern] {
+			priorities[rule.Priority] = true
+		}
+	}
+	for _, rule := range config.Rules {
+		if !changed[rule.Pattern] && (!IsDefaultRule(rule) || !priorities[rule.Priority]) {
+			t.Errorf("rules file holds %q, which wasn't changed", rule.Pattern)
+		}
+	}
+	for pattern := range changed {
+		if !slices.ContainsFunc(config.Rules, func(rule CategorizationRule) bool { return rule.Pattern == pattern }) {
+			t.Errorf("rules file is missing %q", pattern)
+		}
+	}
+	for _, pattern := range []string{".*Netflix.*", ".*Amazon.*", ".*Payment.*"} {
+		if !slices.Contains(config.RemovedDefaults, pattern) {
+			t.Errorf("removed defaults = %v, want %q among them", config.RemovedDefaults, pattern)
+		}
+	}
+
+	if reloaded := NewCategorizer().Rules(); !reflect.DeepEqual(reloaded, rules) {
+		t.Errorf("reloaded rules = %v, want %v", ruleNames(reloaded), ruleNames(rules))
+	}
+}
+
+func TestRulesFileReplacingDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Written by older versions once the rules were managed in the app
+	data, err := json.Marshal(CategoryConfig{Rules: managedRules()[:2], ReplaceDefaults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rulesPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCategorizer()
+	if got := ruleNames(c.Rules()); !slices.Equal(got, []string{"A", "B"}) {
+		t.Fatalf("rules = %v, want only the saved ones", got)
+	}
+	if err := c.ToggleRule(0); err != nil {
+		t.Fatal(err)
+	}
+	if config := readRulesFile(t); config.ReplaceDefaults || len(config.RemovedDefaults) != len(defaultRules) {
+		t.Errorf("rules file replaces defaults = %v with %d removed, want every default removed instead", config.ReplaceDefaults, len(config.RemovedDefaults))
+	}
+	if got := ruleNames(NewCategorizer().Rules()); !slices.Equal(got, []string{"A", "B"}) {
+		t.Errorf("reloaded rules = %v, want only the saved ones", got)
+	}
+}
@@ -0,0 +1,259 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// MatchStats counts a rule's reviewed suggestions and how many of them the
+// user changed
+type MatchStats struct {
+	Matches   int `json:"matches"`
+	Overrides int `json:"overrides"`
+}
+
+// Precision is the share of suggestions the user kept, or 0 before any
+func (m MatchStats) Precision() float64 {
+	if m.Matches == 0 {
+		return 0
+	}
+	return float64(m.Matches-m.Overrides) / float64(m.Matches)
+}
+
+// How many reviewed suggestions the confidence calibrated from counts for:
+// a rule's own record soon outweighs it, the other rules' takes longer
+const (
+	ruleCalibrationWeight   = 5
+	pooledCalibrationWeight = 20
+)
+
+// smoothed is the precision, starting from prior, counted as weight
+// suggestions, and moving toward the real one as they are reviewed
+func (m MatchStats) smoothed(prior, weight float64) float64 {
+	return (float64(m.Matches-m.Overrides) + prior*weight) / (float64(m.Matches) + weight)
+}
+
+func (m *MatchStats) add(other MatchStats) {
+	m.Matches += other.Matches
+	m.Overrides += other.Overrides
+}
+
+// RuleStats is how a rule's suggestions have fared with the user
+type RuleStats struct {
+	// ByMatch has the stats for each way the rule matched
+	ByMatch     map[string]MatchStats `json:"by_match"`
+	LastMatched time.Time             `json:"last_matched,omitempty"`
+}
+
+// Total adds up the stats for every way the rule matched
+func (s RuleStats) Total() MatchStats {
+	var total MatchStats
+	for _, stats := range s.ByMatch {
+		total.add(stats)
+	}
+	return total
+}
+
+// key identifies a rule in the stats. Its priority, bounds and the like can
+// change without losing the rule's record.
+func (rule CategorizationRule) key() string {
+	return strings.Join([]string{rule.Category, rule.Pattern, strings.Join(rule.Keywords, ",")}, "\x00")
+}
+
+// ruleStatsStore keeps the rules' stats and saves them to the stats file
+type ruleStatsStore struct {
+	mu    sync.RWMutex
+	rules map[string]RuleStats
+	// pooled adds up every rule's stats by the way they matched
+	pooled map[string]MatchStats
+}
+
+type ruleStatsFile struct {
+	Rules map[string]RuleStats `json:"rules"`
+}
+
+func statsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".budget_tui_rule_stats.json")
+}
+
+func newRuleStatsStore() *ruleStatsStore {
+	return &ruleStatsStore{rules: make(map[string]RuleStats), pooled: make(map[string]MatchStats)}
+}
+
+// loadRuleStats reads the stats file, starting afresh when there is none
+func loadRuleStats() *ruleStatsStore {
+	store := newRuleStatsStore()
+	data, err := os.ReadFile(statsPath())
+	if err != nil {
+		return store
+	}
+	var file ruleStatsFile
+	if json.Unmarshal(data, &file) != nil || file.Rules == nil {
+		return store
+	}
+	store.rules = file.Rules
+	for _, stats := range store.rules {
+		for matchedBy, match := range stats.ByMatch {
+			pooled := store.pooled[matchedBy]
+			pooled.add(match)
+			store.pooled[matchedBy] = pooled
+		}
+	}
+	return store
+}
+
+func (s *ruleStatsStore) save() error {
+	data, err := json.MarshalIndent(ruleStatsFile{Rules: s.rules}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statsPath(), data, 0644)
+}
+
+// record adds outcomes to a rule's stats; the caller holds the lock
+func (s *ruleStatsStore) record(key, matchedBy string, outcome MatchStats, when time.Time) {
+	stats := s.rules[key]
+	if stats.ByMatch == nil {
+		stats.ByMatch = make(map[string]MatchStats)
+	}
+	match := stats.ByMatch[matchedBy]
+	match.add(outcome)
+	stats.ByMatch[matchedBy] = match
+	if outcome.Matches > 0 && when.After(stats.LastMatched) {
+		stats.LastMatched = when
+	}
+	s.rules[key] = stats
+
+	pooled := s.pooled[matchedBy]
+	pooled.add(outcome)
+	s.pooled[matchedBy] = pooled
+}
+
+func (s *ruleStatsStore) get(key string) RuleStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules[key]
+}
+
+// calibrated is the confidence a rule's match has earned in place of the
+// default one for its kind of match. Until the rule has a record of its
+// own, it leans on how the other rules' matches of that kind have done.
+func (s *ruleStatsStore) calibrated(key, matchedBy string, confidence float64) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	own := s.rules[key].ByMatch[matchedBy]
+	others := s.pooled[matchedBy]
+	others.Matches -= own.Matches
+	others.Overrides -= own.Overrides
+	if others.Matches > 0 {
+		confidence = others.smoothed(confidence, pooledCalibrationWeight)
+	}
+	if own.Matches > 0 {
+		confidence = own.smoothed(confidence, ruleCalibrationWeight)
+	}
+	return confidence
+}
+
+// calibrate replaces a match's default confidence with the one its rule has
+// earned
+func (c *Categorizer) calibrate(evaluation RuleEvaluation, key, description string) RuleEvaluation {
+	if !evaluation.Matched {
+		return evaluation
+	}
+	evaluation.BaseConfidence = c.stats.calibrated(key, evaluation.MatchedBy, evaluation.DefaultConfidence)
+	evaluation.Confidence, evaluation.Bonus = evaluation.Rule.scale(description, evaluation.BaseConfidence)
+	return evaluation
+}
+
+// RecordOutcomes keeps score of the rules that suggested the categories of
+// transactions the user has just reviewed, such as a confirmed import. A
+// suggestion the user changed counts against its rule.
+func (c *Categorizer) RecordOutcomes(transactions []budget.Transaction) error {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	for _, t := range transactions {
+		if t.CategorizedBy == "" {
+			continue
+		}
+		outcome := MatchStats{Matches: 1}
+		if t.ManualCategory {
+			outcome.Overrides = 1
+		}
+		c.stats.record(t.CategorizedBy, t.MatchedBy, outcome, t.Date)
+	}
+	return c.stats.save()
+}
+
+// RecordOverride counts it against the rule that categorized t when the
+// user changes its category afterwards. The match itself was counted when
+// t was imported.
+func (c *Categorizer) RecordOverride(t budget.Transaction) error {
+	if t.CategorizedBy == "" || t.ManualCategory {
+		return nil
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	c.stats.record(t.CategorizedBy, t.MatchedBy, MatchStats{Overrides: 1}, t.Date)
+	return c.stats.save()
+}
+
+// RecordRejected counts planned recategorizations the user left out
+// against the rules that proposed them
+func (c *Categorizer) RecordRejected(changes []CategoryChange) error {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	for _, change := range changes {
+		if change.Rule != "" {
+			c.stats.record(change.Rule, change.MatchedBy, MatchStats{Matches: 1, Overrides: 1}, change.Date)
+		}
+	}
+	return c.stats.save()
+}
+
+// RuleReport is a rule's record for the rule statistics report
+type RuleReport struct {
+	Rule  CategorizationRule
+	Stats RuleStats
+	// Confidence has, for each way the rule can match, the confidence it
+	// has earned and the default it started from
+	Confidence        map[string]float64
+	DefaultConfidence map[string]float64
+}
+
+// RuleReports returns every rule's record, in the order the rules are tried
+func (c *Categorizer) RuleReports() []RuleReport {
+	var reports []RuleReport
+	for _, rule := range c.Rules() {
+		reports = append(reports, c.ReportRule(rule))
+	}
+	return reports
+}
+
+// ReportRule returns a rule's record
+func (c *Categorizer) ReportRule(rule CategorizationRule) RuleReport {
+	report := RuleReport{
+		Rule:              rule,
+		Stats:             c.stats.get(rule.key()),
+		Confidence:        make(map[string]float64),
+		DefaultConfidence: make(map[string]float64),
+	}
+	if rule.Pattern != "" {
+		report.DefaultConfidence[matchedPattern] = patternScore
+	}
+	if len(rule.Keywords) > 0 {
+		report.DefaultConfidence[matchedKeyword] = keywordScore
+	}
+	if len(report.DefaultConfidence) == 0 {
+		report.DefaultConfidence[matchedConditions] = conditionsScore
+	}
+	for matchedBy, confidence := range report.DefaultConfidence {
+		report.Confidence[matchedBy] = c.stats.calibrated(rule.key(), matchedBy, confidence)
+	}
+	return report
+}
@@ -0,0 +1,104 @@
+package categorizer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestMatchStatsPrecision(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats MatchStats
+		want  float64
+	}{
+		{"none", MatchStats{}, 0},
+		{"all kept", MatchStats{Matches: 4}, 1},
+		{"some overridden", MatchStats{Matches: 4, Overrides: 1}, 0.75},
+		{"all overridden", MatchStats{Matches: 2, Overrides: 2}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.Precision(); got != tt.want {
+				t.Errorf("Precision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordOutcomes(t *testing.T) {
+	coffee := CategorizationRule{Pattern: ".*starbucks.*", Category: "Coffee", Priority: 1, IsActive: true}
+	suggested := func(manual bool) budget.Transaction {
+		return budget.Transaction{Description: "STARBUCKS", Category: "Coffee", CategorizedBy: coffee.key(),
+			MatchedBy: matchedPattern, ManualCategory: manual, Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)}
+	}
+
+	tests := []struct {
+		name string
+		// reviewed are recorded as a confirmed import, then overridden
+		// are changed by hand afterwards
+		reviewed   []budget.Transaction
+		overridden []budget.Transaction
+		want       MatchStats
+	}{
+		{"kept", []budget.Transaction{suggested(false), suggested(false)}, nil, MatchStats{Matches: 2}},
+		{"changed in review", []budget.Transaction{suggested(false), suggested(true)}, nil, MatchStats{Matches: 2, Overrides: 1}},
+		{"changed later", []budget.Transaction{suggested(false), suggested(false), suggested(false), suggested(false)},
+			[]budget.Transaction{suggested(false)}, MatchStats{Matches: 4, Overrides: 1}},
+		{"already changed", []budget.Transaction{suggested(true)}, []budget.Transaction{suggested(true)}, MatchStats{Matches: 1, Overrides: 1}},
+		{"no rule", []budget.Transaction{{Description: "cash"}}, []budget.Transaction{{Description: "cash"}}, MatchStats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			c := testCategorizer([]CategorizationRule{coffee})
+			if err := c.RecordOutcomes(tt.reviewed); err != nil {
+				t.Fatal(err)
+			}
+			for _, transaction := range tt.overridden {
+				if err := c.RecordOverride(transaction); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := c.stats.get(coffee.key()).Total(); got != tt.want {
+				t.Errorf("stats = %+v, want %+v", got, tt.want)
+			}
+			// The stats survive a restart
+			if got := loadRuleStats().get(coffee.key()).Total(); got != tt.want {
+				t.Errorf("saved stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibrated(t *testing.T) {
+	store := newRuleStatsStore()
+	store.record("kept", matchedPattern, MatchStats{Matches: 10}, time.Time{})
+	store.record("overridden", matchedKeyword, MatchStats{Matches: 10, Overrides: 10}, time.Time{})
+
+	tests := []struct {
+		name      string
+		key       string
+		matchedBy string
+		want      float64
+	}{
+		{"no record at all", "new", matchedConditions, conditionsScore},
+		{"own record", "kept", matchedPattern, (10 + patternScore*ruleCalibrationWeight) / (10 + ruleCalibrationWeight)},
+		{"other rules' record", "new", matchedPattern, (10 + patternScore*pooledCalibrationWeight) / (10 + pooledCalibrationWeight)},
+		{"poor record", "overridden", matchedKeyword, keywordScore * ruleCalibrationWeight / (10 + ruleCalibrationWeight)},
+		{"other kind of match", "kept", matchedKeyword, keywordScore * pooledCalibrationWeight / (10 + pooledCalibrationWeight)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults := map[string]float64{matchedPattern: patternScore, matchedKeyword: keywordScore, matchedConditions: conditionsScore}
+			if got := store.calibrated(tt.key, tt.matchedBy, defaults[tt.matchedBy]); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("calibrated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package categorizer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+// RuleSuggestion is a rule proposed for a group of uncategorized
+// transactions that share a merchant
+type RuleSuggestion struct {
+	Rule CategorizationRule
+	// Matches are the indexes of the uncategorized transactions the rule
+	// would categorize
+	Matches []int
+	// Examples are a few of their descriptions
+	Examples []string
+}
+
+// suggestedRulePriority ranks suggested rules above the general default
+// rules but below the specific merchants
+const suggestedRulePriority = 90
+
+// minSuggestionMatches is how many uncategorized transactions a merchant
+// needs before a rule is suggested for it
+const minSuggestionMatches = 2
+
+// noiseWords appear in many bank descriptions without naming the merchant
+var noiseWords = map[string]bool{
+	"pos": true, "purchase": true, "debit": true, "credit": true, "card": true,
+	"visa": true, "mastercard": true, "ach": true, "online": true, "recurring": true,
+	"transaction": true, "payment": true, "the": true, "www": true, "com": true,
+	"inc": true, "llc": true, "ltd": true, "sq": true, "tst": true, "paypal": true,
+}
+
+// merchantWords returns the words of a description that could name its
+// merchant, in order
+func merchantWords(description string) []string {
+	var merchant []string
+	for _, word := range words(description) {
+		if len(word) >= 3 && !noiseWords[word] {
+			merchant = append(merchant, word)
+		}
+	}
+	return merchant
+}
+
+// SuggestRules groups the uncategorized transactions by merchant and
+// proposes a rule for each group, most common merchant first. The category
+// is the one the user most often gave the merchant's other transactions, or
+// the one learned for it; it is left empty when nothing is known.
+func (c *Categorizer) SuggestRules(transactions []budget.Transaction) []RuleSuggestion {
+	groups := make(map[string][]int)
+	var keys []string
+	for i, t := range transactions {
+		if learnable(t) {
+			continue
+		}
+		merchant := merchantWords(t.Description)
+		if len(merchant) == 0 {
+			continue
+		}
+		if groups[merchant[0]] == nil {
+			keys = append(keys, merchant[0])
+		}
+		groups[merchant[0]] = append(groups[merchant[0]], i)
+	}
+
+	var suggestions []RuleSuggestion
+	for _, key := range keys {
+		matches := groups[key]
+		if len(matches) < minSuggestionMatches {
+			continue
+		}
+
+		merchant := commonMerchant(transactions, matches)
+		keyword := strings.Join(merchant, " ")
+		quoted := make([]string, len(merchant))
+		for i, word := range merchant {
+			quoted[i] = regexp.QuoteMeta(word)
+		}
+		rule := CategorizationRule{
+			Pattern:  ".*" + strings.Join(quoted, ".*") + ".*",
+			Keywords: []string{keyword},
+			Category: c.suggestCategory(transactions, matches, keyword),
+			Priority: suggestedRulePriority,
+			IsActive: true,
+		}
+
+		// Limit the rule to income or expenses when the group does
+		transType := transactions[matches[0]].Type
+		for _, i := range matches {
+			if transactions[i].Type != transType {
+				transType = ""
+				break
+			}
+		}
+		rule.TransactionType = transType
+
+		suggestion := RuleSuggestion{Rule: rule, Matches: matches}
+		for _, i := range matches[:min(3, len(matches))] {
+			suggestion.Examples = append(suggestion.Examples, transactions[i].Description)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return len(suggestions[i].Matches) > len(suggestions[j].Matches)
+	})
+	return suggestions
+}
+
+// commonMerchant is the longest run of merchant words every transaction in
+// the group starts with, such as "whole foods"
+func commonMerchant(transactions []budget.Transaction, matches []int) []string {
+	common := merchantWords(transactions[matches[0]].Description)
+	for _, i := range matches[1:] {
+		merchant := merchantWords(transactions[i].Description)
+		n := 0
+		for n < len(common) && n < len(merchant) && common[n] == merchant[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return common
+}
+
+// suggestCategory picks a category for a merchant from the categorized
+// transactions whose description contains keyword, or else from what the
+// classifier has learned
+func (c *Categorizer) suggestCategory(transactions []budget.Transaction, matches []int, keyword string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, t := range transactions {
+		if !learnable(t) || !strings.Contains(strings.Join(merchantWords(t.Description), " "), keyword) {
+			continue
+		}
+		counts[t.Category]++
+		if best == "" || counts[t.Category] > counts[best] {
+			best = t.Category
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	t := transactions[matches[0]]
+	if learned, _ := c.learned.Predict(t.Description, t.Amount, t.Type); learned != "" {
+		return learned
+	}
+	return ""
+}
+
+// RuleMatches returns the indexes of the transactions rule applies to
+func (c *Categorizer) RuleMatches(rule CategorizationRule, transactions []budget.Transaction) []int {
+	var matches []int
+	for i, t := range transactions {
+		if rule.evaluate(newSubject(t)).Matched {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,128 @@
+package categorizer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Elwdipath/budget_tui/internal/budget"
+)
+
+func TestSuggestRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	spent := func(description, category string) budget.Transaction {
+		return budget.Transaction{Description: description, Category: category, Amount: 10, Type: budget.Expense}
+	}
+	earned := func(description string) budget.Transaction {
+		return budget.Transaction{Description: description, Category: "Uncategorized", Amount: 10, Type: budget.Income}
+	}
+
+	tests := []struct {
+		name         string
+		transactions []budget.Transaction
+		wantKeywords []string
+		wantCategory []string
+		wantType     []budget.TransactionType
+		wantMatches  [][]int
+	}{
+		{
+			name: "common merchant words",
+			transactions: []budget.Transaction{
+				spent("POS PURCHASE WHOLE FOODS #101", "Uncategorized"),
+				spent("WHOLE FOODS MARKET 22", "Uncategorized"),
+			},
+			wantKeywords: []string{"whole foods"},
+			wantCategory: []string{""},
+			wantType:     []budget.TransactionType{budget.Expense},
+			wantMatches:  [][]int{{0, 1}},
+		},
+		{
+			name: "one transaction is too few",
+			transactions: []budget.Transaction{
+				spent("ACME HARDWARE", "Uncategorized"),
+				spent("ZENITH BOOKS", "Uncategorized"),
+			},
+		},
+		{
+			name: "category from history",
+			transactions: []budget.Transaction{
+				spent("Acme Hardware 1", "Uncategorized"),
+				spent("Acme Hardware 2", "Uncategorized"),
+				spent("ACME HARDWARE 3", "Home"),
+				spent("ACME HARDWARE 4", "Home"),
+				spent("ACME HARDWARE 5", "Shopping"),
+			},
+			wantKeywords: []string{"acme hardware"},
+			wantCategory: []string{"Home"},
+			wantType:     []budget.TransactionType{budget.Expense},
+			wantMatches:  [][]int{{0, 1}},
+		},
+		{
+			name: "mixed types",
+			transactions: []budget.Transaction{
+				spent("VENMO alice", "Uncategorized"),
+				earned("VENMO bob"),
+			},
+			wantKeywords: []string{"venmo"},
+			wantCategory: []string{""},
+			wantType:     []budget.TransactionType{""},
+			wantMatches:  [][]int{{0, 1}},
+		},
+		{
+			name: "largest group first",
+			transactions: []budget.Transaction{
+				spent("ZENITH BOOKS", "Uncategorized"),
+				spent("ZENITH BOOKS", "Uncategorized"),
+				spent("ORBIT CAFE", "Uncategorized"),
+				spent("ORBIT CAFE", "Uncategorized"),
+				spent("ORBIT CAFE", "Uncategorized"),
+			},
+			wantKeywords: []string{"orbit cafe", "zenith books"},
+			wantCategory: []string{"", ""},
+			wantType:     []budget.TransactionType{budget.Expense, budget.Expense},
+			wantMatches:  [][]int{{2, 3, 4}, {0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := NewCategorizer().SuggestRules(tt.transactions)
+			if len(suggestions) != len(tt.wantKeywords) {
+				t.Fatalf("SuggestRules() made %d suggestions, want %d", len(suggestions), len(tt.wantKeywords))
+			}
+			for i, suggestion := range suggestions {
+				rule := suggestion.Rule
+				if !slices.Equal(rule.Keywords, []string{tt.wantKeywords[i]}) {
+					t.Errorf("suggestion %d keywords = %q, want %q", i, rule.Keywords, tt.wantKeywords[i])
+				}
+				if rule.Category != tt.wantCategory[i] {
+					t.Errorf("suggestion %d category = %q, want %q", i, rule.Category, tt.wantCategory[i])
+				}
+				if rule.TransactionType != tt.wantType[i] {
+					t.Errorf("suggestion %d type = %q, want %q", i, rule.TransactionType, tt.wantType[i])
+				}
+				if !slices.Equal(suggestion.Matches, tt.wantMatches[i]) {
+					t.Errorf("suggestion %d matches = %v, want %v", i, suggestion.Matches, tt.wantMatches[i])
+				}
+			}
+		})
+	}
+}
+
+// TestSuggestedRuleMatchesItsGroup checks that a suggested rule, once
+// saved, categorizes the transactions it was suggested for
+func TestSuggestedRuleMatchesItsGroup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	transactions := []budget.Transaction{
+		{Description: "SQ *BLUE BOTTLE COFFEE 1", Category: "Uncategorized", Amount: 5, Type: budget.Expense},
+		{Description: "Blue Bottle Coffee Oakland", Category: "Uncategorized", Amount: 6, Type: budget.Expense},
+		{Description: "Philz Coffee", Category: "Uncategorized", Amount: 4, Type: budget.Expense},
+	}
+	c := NewCategorizer()
+	suggestions := c.SuggestRules(transactions)
+	if len(suggestions) != 1 {
+		t.Fatalf("SuggestRules() made %d suggestions, want 1", len(suggestions))
+	}
+	if got := c.RuleMatches(suggestions[0].Rule, transactions); !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("RuleMatches() = %v, want [0 1]", got)
+	}
+}